@@ -0,0 +1,126 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ucfg
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ByteSize represents a size in bytes. ByteSize values can be unpacked from
+// plain numbers (bytes) or from strings with an SI (KB, MB, ...) or IEC (KiB,
+// MiB, ...) unit suffix like "10MiB" or "1.5 GB".
+type ByteSize uint64
+
+// Byte size units. SI units are powers of 1000, IEC units powers of 1024.
+const (
+	B ByteSize = 1
+
+	KB ByteSize = 1000 * B
+	MB          = 1000 * KB
+	GB          = 1000 * MB
+	TB          = 1000 * GB
+	PB          = 1000 * TB
+	EB          = 1000 * PB
+
+	KiB ByteSize = 1024 * B
+	MiB          = 1024 * KiB
+	GiB          = 1024 * MiB
+	TiB          = 1024 * GiB
+	PiB          = 1024 * TiB
+	EiB          = 1024 * PiB
+)
+
+var byteSizeUnits = map[string]ByteSize{
+	"":  B,
+	"b": B,
+
+	"k": KB, "kb": KB,
+	"m": MB, "mb": MB,
+	"g": GB, "gb": GB,
+	"t": TB, "tb": TB,
+	"p": PB, "pb": PB,
+	"e": EB, "eb": EB,
+
+	"ki": KiB, "kib": KiB,
+	"mi": MiB, "mib": MiB,
+	"gi": GiB, "gib": GiB,
+	"ti": TiB, "tib": TiB,
+	"pi": PiB, "pib": PiB,
+	"ei": EiB, "eib": EiB,
+}
+
+// formatting order, larger units first, IEC units preferred
+var byteSizeFormats = []struct {
+	unit ByteSize
+	name string
+}{
+	{EiB, "EiB"}, {EB, "EB"},
+	{PiB, "PiB"}, {PB, "PB"},
+	{TiB, "TiB"}, {TB, "TB"},
+	{GiB, "GiB"}, {GB, "GB"},
+	{MiB, "MiB"}, {MB, "MB"},
+	{KiB, "KiB"}, {KB, "KB"},
+}
+
+// ParseByteSize parses a size in bytes with an optional unit suffix. Units are
+// case insensitive. Returns ErrInvalidByteSize if s is not a valid size.
+func ParseByteSize(s string) (ByteSize, error) {
+	str := strings.TrimSpace(s)
+	split := strings.LastIndexFunc(str, func(r rune) bool {
+		return unicode.IsDigit(r) || r == '.'
+	}) + 1
+
+	num := str[:split]
+	unit, ok := byteSizeUnits[strings.ToLower(strings.TrimSpace(str[split:]))]
+	if num == "" || !ok {
+		return 0, fmt.Errorf("%w: '%v'", ErrInvalidByteSize, s)
+	}
+
+	if n, err := strconv.ParseUint(num, 10, 64); err == nil {
+		if n > math.MaxUint64/uint64(unit) {
+			return 0, fmt.Errorf("%w: '%v' overflows", ErrInvalidByteSize, s)
+		}
+		return ByteSize(n) * unit, nil
+	}
+
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("%w: '%v'", ErrInvalidByteSize, s)
+	}
+	size := math.Round(f * float64(unit))
+	if size >= math.MaxUint64 {
+		return 0, fmt.Errorf("%w: '%v' overflows", ErrInvalidByteSize, s)
+	}
+	return ByteSize(size), nil
+}
+
+// String formats the size using the largest unit the size is a multiple of.
+func (b ByteSize) String() string {
+	if b != 0 {
+		for _, f := range byteSizeFormats {
+			if b%f.unit == 0 {
+				return fmt.Sprintf("%d%v", b/f.unit, f.name)
+			}
+		}
+	}
+	return fmt.Sprintf("%dB", uint64(b))
+}
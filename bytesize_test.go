@@ -0,0 +1,93 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ucfg
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]ByteSize{
+		"0":      0,
+		"512":    512,
+		"512B":   512,
+		"1KB":    1000,
+		"1kb":    1000,
+		"1KiB":   1024,
+		"10MiB":  10 * MiB,
+		"10 MB":  10 * MB,
+		"1.5GiB": GiB + 512*MiB,
+		"2TiB":   2 * TiB,
+		" 3 gi ": 3 * GiB,
+		"1EiB":   EiB,
+		"0.5KB":  500,
+	}
+
+	for in, expected := range tests {
+		t.Run(in, func(t *testing.T) {
+			actual, err := ParseByteSize(in)
+			require.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestParseByteSizeFail(t *testing.T) {
+	tests := []string{
+		"",
+		"MiB",
+		"10 bytes",
+		"-1KB",
+		"1.2.3MB",
+		"20EiB",
+	}
+
+	for _, in := range tests {
+		t.Run(in, func(t *testing.T) {
+			_, err := ParseByteSize(in)
+			assert.True(t, errors.Is(err, ErrInvalidByteSize), "unexpected error: %v", err)
+		})
+	}
+}
+
+func TestByteSizeString(t *testing.T) {
+	tests := map[ByteSize]string{
+		0:          "0B",
+		1:          "1B",
+		1000:       "1KB",
+		1024:       "1KiB",
+		1500:       "1500B",
+		10 * MiB:   "10MiB",
+		3 * GB:     "3GB",
+		GiB + 1:    "1073741825B",
+		1536 * MiB: "1536MiB",
+		4 * EiB:    "4EiB",
+	}
+
+	for in, expected := range tests {
+		assert.Equal(t, expected, in.String())
+
+		parsed, err := ParseByteSize(expected)
+		require.NoError(t, err)
+		assert.Equal(t, in, parsed)
+	}
+}
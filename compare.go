@@ -0,0 +1,228 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ucfg
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// canonicalConfig is the comparable representation of a Config object, not
+// depending on meta data or contexts.
+type canonicalConfig struct {
+	dict map[string]interface{}
+	arr  []interface{}
+}
+
+// canonicalDynamic represents an unresolved variable expansion by its
+// expression.
+type canonicalDynamic string
+
+// Clone creates a deep copy of c. The copy is a new root configuration, not
+// connected to the parent of c. Meta data is preserved, and variable
+// expansions are copied unresolved. Clone returns nil if c is nil.
+func (c *Config) Clone() *Config {
+	if c == nil {
+		return nil
+	}
+	return cfgSub{c}.cpy(context{}).(cfgSub).c
+}
+
+// Equal checks if c and other contain the same settings and values. Meta data
+// is ignored. Signed and unsigned integers with the same value are considered
+// equal.
+//
+// Variable expansions are compared by their expressions. If the
+// ResolveDynamic option is set, variables are resolved and the resolved
+// values are compared instead. Equal returns false if a variable can not be
+// resolved.
+//
+// Equal supports the options: PathSep, Env, Resolve, ResolveEnv, ResolveDynamic
+func (c *Config) Equal(other *Config, opts ...Option) bool {
+	if c == nil || other == nil {
+		return c == other
+	}
+
+	o := makeOptions(opts)
+	a, err := canonicalValue(o, cfgSub{c})
+	if err != nil {
+		return false
+	}
+	b, err := canonicalValue(o, cfgSub{other})
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// Hash computes a stable 64-bit FNV-1a hash of the settings and values in c.
+// Configurations reported as equal by Equal, given the same options, have the
+// same hash, independent of the order keys have been added to c.
+//
+// Variable expansions are hashed by their expressions, unless the
+// ResolveDynamic option is set. An error is returned if a variable can not be
+// resolved. The hash of a nil configuration is 0.
+//
+// Hash supports the options: PathSep, Env, Resolve, ResolveEnv, ResolveDynamic
+func (c *Config) Hash(opts ...Option) (uint64, error) {
+	if c == nil {
+		return 0, nil
+	}
+
+	v, err := canonicalValue(makeOptions(opts), cfgSub{c})
+	if err != nil {
+		return 0, err
+	}
+
+	h := fnv.New64a()
+	hashCanonical(h, v)
+	return h.Sum64(), nil
+}
+
+func canonicalValue(opts *options, v value) (interface{}, error) {
+	switch x := v.(type) {
+	case cfgSub:
+		return canonicalSub(opts, x.c)
+
+	case *cfgDynamic:
+		if !opts.resolveDynamic {
+			return canonicalDynamicExpr(x), nil
+		}
+		resolved, err := x.getValue(opts)
+		if err != nil {
+			return nil, err
+		}
+		return canonicalValue(opts, resolved)
+
+	case *cfgNil:
+		return nil, nil
+	}
+
+	r, err := v.reify(opts)
+	if err != nil {
+		return nil, err
+	}
+	if i, ok := r.(int64); ok && i >= 0 {
+		return uint64(i), nil
+	}
+	return r, nil
+}
+
+func canonicalSub(opts *options, c *Config) (canonicalConfig, error) {
+	var canonical canonicalConfig
+
+	if dict := c.fields.dict(); len(dict) > 0 {
+		canonical.dict = make(map[string]interface{}, len(dict))
+		for k, v := range dict {
+			cv, err := canonicalValue(opts, v)
+			if err != nil {
+				return canonicalConfig{}, err
+			}
+			canonical.dict[k] = cv
+		}
+	}
+
+	if arr := c.fields.array(); arr != nil {
+		canonical.arr = make([]interface{}, len(arr))
+		for i, v := range arr {
+			cv, err := canonicalValue(opts, v)
+			if err != nil {
+				return canonicalConfig{}, err
+			}
+			canonical.arr[i] = cv
+		}
+	}
+
+	return canonical, nil
+}
+
+func canonicalDynamicExpr(d *cfgDynamic) canonicalDynamic {
+	if s, ok := d.dyn.(spliceDynValue); ok {
+		return canonicalDynamic(formatVarExp(s.e))
+	}
+	return canonicalDynamic(d.dyn.String())
+}
+
+func hashCanonical(h hash.Hash64, v interface{}) {
+	var buf [8]byte
+	writeUint := func(u uint64) {
+		binary.BigEndian.PutUint64(buf[:], u)
+		h.Write(buf[:])
+	}
+	writeString := func(s string) {
+		writeUint(uint64(len(s)))
+		h.Write([]byte(s))
+	}
+
+	switch x := v.(type) {
+	case nil:
+		h.Write([]byte{'n'})
+	case bool:
+		if x {
+			h.Write([]byte{'t'})
+		} else {
+			h.Write([]byte{'f'})
+		}
+	case int64:
+		h.Write([]byte{'i'})
+		writeUint(uint64(x))
+	case uint64:
+		h.Write([]byte{'u'})
+		writeUint(x)
+	case float64:
+		h.Write([]byte{'d'})
+		writeUint(math.Float64bits(x))
+	case string:
+		h.Write([]byte{'s'})
+		writeString(x)
+	case canonicalDynamic:
+		h.Write([]byte{'v'})
+		writeString(string(x))
+	case canonicalConfig:
+		keys := make([]string, 0, len(x.dict))
+		for k := range x.dict {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		h.Write([]byte{'o'})
+		writeUint(uint64(len(keys)))
+		for _, k := range keys {
+			writeString(k)
+			hashCanonical(h, x.dict[k])
+		}
+
+		if x.arr == nil {
+			h.Write([]byte{'x'})
+			return
+		}
+		h.Write([]byte{'a'})
+		writeUint(uint64(len(x.arr)))
+		for _, elem := range x.arr {
+			hashCanonical(h, elem)
+		}
+	default:
+		h.Write([]byte{'?'})
+		writeString(fmt.Sprint(x))
+	}
+}
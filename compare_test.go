@@ -0,0 +1,199 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ucfg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClone(t *testing.T) {
+	c := MustNewFrom(map[string]interface{}{
+		"a": map[string]interface{}{
+			"b":    1,
+			"list": []interface{}{"x", map[string]interface{}{"y": true}},
+		},
+		"ref": "${a.b}",
+	}, PathSep("."), VarExp, MetaData(Meta{Source: "test.yml"}))
+
+	sub, err := c.Child("a", -1)
+	require.NoError(t, err)
+
+	clone := c.Clone()
+	assert.True(t, c.Equal(clone))
+	assert.False(t, c == clone)
+
+	// modifying the clone does not change the original
+	require.NoError(t, clone.SetInt("a.b", -1, 2, PathSep(".")))
+	b, err := c.Int("a.b", -1, PathSep("."))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), b)
+
+	// references are resolved within the clone
+	ref, err := clone.Int("ref", -1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), ref)
+
+	meta, err := clone.MetaOf("a.list", 0, PathSep("."))
+	require.NoError(t, err)
+	assert.Equal(t, "test.yml", meta.Source)
+
+	// clones of sub-configurations are new root configurations
+	subClone := sub.Clone()
+	assert.Nil(t, subClone.Parent())
+	assert.Equal(t, "", subClone.Path("."))
+	assert.Equal(t, "list.1", subClone.PathOf("list.1", "."))
+	assert.True(t, sub.Equal(subClone))
+}
+
+func TestEqualAndHash(t *testing.T) {
+	base := map[string]interface{}{
+		"name":  "beat",
+		"count": 3,
+		"neg":   -1,
+		"ratio": 0.5,
+		"tags":  []interface{}{"a", "b"},
+		"empty": []interface{}{},
+		"nil":   nil,
+		"sub":   map[string]interface{}{"enabled": true},
+	}
+
+	cases := map[string]struct {
+		other map[string]interface{}
+		equal bool
+	}{
+		"same": {
+			other: base,
+			equal: true,
+		},
+		"int types": {
+			other: map[string]interface{}{
+				"name":  "beat",
+				"count": uint8(3),
+				"neg":   int64(-1),
+				"ratio": 0.5,
+				"tags":  []string{"a", "b"},
+				"empty": []string{},
+				"nil":   nil,
+				"sub":   map[string]bool{"enabled": true},
+			},
+			equal: true,
+		},
+		"value differs": {
+			other: map[string]interface{}{
+				"name": "other", "count": 3, "neg": -1, "ratio": 0.5, "tags": []interface{}{"a", "b"},
+				"empty": []interface{}{}, "nil": nil, "sub": map[string]interface{}{"enabled": true},
+			},
+		},
+		"array order differs": {
+			other: map[string]interface{}{
+				"name": "beat", "count": 3, "neg": -1, "ratio": 0.5, "tags": []interface{}{"b", "a"},
+				"empty": []interface{}{}, "nil": nil, "sub": map[string]interface{}{"enabled": true},
+			},
+		},
+		"missing key": {
+			other: map[string]interface{}{
+				"name": "beat", "count": 3, "neg": -1, "ratio": 0.5, "tags": []interface{}{"a", "b"},
+				"empty": []interface{}{}, "nil": nil,
+			},
+		},
+		"type differs": {
+			other: map[string]interface{}{
+				"name": "beat", "count": "3", "neg": -1, "ratio": 0.5, "tags": []interface{}{"a", "b"},
+				"empty": []interface{}{}, "nil": nil, "sub": map[string]interface{}{"enabled": true},
+			},
+		},
+	}
+
+	for name, test := range cases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			a := MustNewFrom(base, MetaData(Meta{Source: "a.yml"}))
+			b := MustNewFrom(test.other, MetaData(Meta{Source: "b.yml"}))
+
+			assert.Equal(t, test.equal, a.Equal(b))
+			assert.Equal(t, test.equal, b.Equal(a))
+
+			ha, err := a.Hash()
+			require.NoError(t, err)
+			hb, err := b.Hash()
+			require.NoError(t, err)
+			if test.equal {
+				assert.Equal(t, ha, hb)
+			} else {
+				assert.NotEqual(t, ha, hb)
+			}
+		})
+	}
+}
+
+func TestHashStable(t *testing.T) {
+	in := map[string]interface{}{}
+	for _, k := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		in[k] = map[string]interface{}{"x": k, "y": []interface{}{k, 1}}
+	}
+
+	expected, err := MustNewFrom(in).Hash()
+	require.NoError(t, err)
+	for i := 0; i < 20; i++ {
+		h, err := MustNewFrom(in).Hash()
+		require.NoError(t, err)
+		assert.Equal(t, expected, h)
+	}
+
+	// the same keys in a nested object produce a different hash
+	nested, err := MustNewFrom(map[string]interface{}{"sub": in}).Hash()
+	require.NoError(t, err)
+	assert.NotEqual(t, expected, nested)
+}
+
+func TestEqualResolveDynamic(t *testing.T) {
+	a := MustNewFrom(map[string]interface{}{"host": "localhost", "url": "http://${host}:9200"}, VarExp)
+	b := MustNewFrom(map[string]interface{}{"host": "localhost", "url": "http://localhost:9200"}, VarExp)
+	c := MustNewFrom(map[string]interface{}{"host": "other", "url": "http://${host}:9200"}, VarExp)
+
+	assert.False(t, a.Equal(b))
+	assert.True(t, a.Equal(b, ResolveDynamic))
+	assert.False(t, a.Equal(c))
+
+	ha, err := a.Hash(ResolveDynamic)
+	require.NoError(t, err)
+	hb, err := b.Hash(ResolveDynamic)
+	require.NoError(t, err)
+	assert.Equal(t, ha, hb)
+
+	missing := MustNewFrom(map[string]interface{}{"url": "${missing:?url required}"}, VarExp)
+	assert.False(t, missing.Equal(missing, ResolveDynamic))
+	assert.True(t, missing.Equal(missing))
+
+	_, err = missing.Hash(ResolveDynamic)
+	assert.Error(t, err)
+}
+
+func TestNilConfig(t *testing.T) {
+	var c *Config
+	assert.Nil(t, c.Clone())
+	assert.True(t, c.Equal(nil))
+	assert.False(t, c.Equal(New()))
+
+	h, err := c.Hash()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), h)
+}
@@ -0,0 +1,114 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ucfg
+
+import "fmt"
+
+// Deprecation describes the use of a deprecated setting, reported by the
+// OnDeprecated option when unpacking a configuration.
+type Deprecation struct {
+	// Path of the setting as used in the configuration.
+	Path string
+
+	// Replacement is the path of the setting to use instead, if the setting
+	// has been set via an alias of a renamed field.
+	Replacement string
+
+	// Message is the text of the `deprecated` struct tag.
+	Message string
+
+	// Meta is the meta data of the setting.
+	Meta *Meta
+}
+
+// String formats the deprecation as warning message.
+func (d Deprecation) String() string {
+	message := fmt.Sprintf("setting '%v' is deprecated", d.Path)
+	if d.Replacement != "" {
+		message = fmt.Sprintf("%v, use '%v' instead", message, d.Replacement)
+	}
+	if d.Message != "" {
+		message = fmt.Sprintf("%v: %v", message, d.Message)
+	}
+	return messageMeta(message, d.Meta)
+}
+
+// resolveFieldName returns the name to read the struct field from. If an
+// alias of the field is set in cfg, the alias is returned. The use of aliases
+// and deprecated fields is reported to the OnDeprecated callback.
+// resolveFieldName fails with ErrConflict if more than one name of the
+// field is set.
+func resolveFieldName(cfg *Config, fInfo fieldInfo) (string, Error) {
+	if len(fInfo.tagOptions.aliases) == 0 && fInfo.deprecated == "" {
+		return fInfo.name, nil
+	}
+
+	opts := fInfo.options
+	used := fInfo.name
+	v, err := lookupFieldName(cfg, used, opts)
+	if err != nil {
+		return "", err
+	}
+
+	for _, alias := range fInfo.tagOptions.aliases {
+		aliasV, err := lookupFieldName(cfg, alias, opts)
+		if err != nil {
+			return "", err
+		}
+		if aliasV == nil {
+			continue
+		}
+		if v != nil {
+			return "", raiseAliasConflict(cfg, fInfo.name, used, alias, aliasV.meta())
+		}
+		used, v = alias, aliasV
+	}
+
+	if v == nil {
+		return fInfo.name, nil
+	}
+
+	if opts.onDeprecated != nil {
+		ctx := v.Context()
+		d := Deprecation{
+			Path:    ctx.quotedPath(),
+			Message: fInfo.deprecated,
+			Meta:    v.meta(),
+		}
+		if used != fInfo.name {
+			d.Replacement = cfg.ctx.quotedPathOf(fInfo.name)
+		} else if d.Message == "" {
+			return used, nil
+		}
+		opts.onDeprecated(d)
+	}
+	return used, nil
+}
+
+// lookupFieldName returns the value of the setting name, or nil if the
+// setting does not exist in cfg.
+func lookupFieldName(cfg *Config, name string, opts *options) (value, Error) {
+	v, err := parseKeyPath(name, opts).GetValue(cfg, opts)
+	if err != nil {
+		if err.Reason() != ErrMissing {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return v, nil
+}
@@ -0,0 +1,148 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ucfg
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type deprecatedOutput struct {
+	Hosts   []string `config:"hosts,alias=host,alias=urls"`
+	Timeout int      `config:"timeout" deprecated:"removed in 9.0"`
+	Index   string   `config:"index"`
+}
+
+type deprecatedSettings struct {
+	Output deprecatedOutput       `config:"output"`
+	Inline map[string]interface{} `config:",inline"`
+}
+
+func unpackDeprecated(t *testing.T, in map[string]interface{}) (deprecatedSettings, []Deprecation, error) {
+	t.Helper()
+
+	cfg, err := NewFrom(in, PathSep("."), MetaData(Meta{Source: "beat.yml"}))
+	require.NoError(t, err)
+
+	var settings deprecatedSettings
+	var deprecations []Deprecation
+	err = cfg.Unpack(&settings, OnDeprecated(func(d Deprecation) {
+		deprecations = append(deprecations, d)
+	}))
+	return settings, deprecations, err
+}
+
+func TestUnpackAlias(t *testing.T) {
+	settings, deprecations, err := unpackDeprecated(t, map[string]interface{}{
+		"output.host":  "localhost:9200",
+		"output.index": "test",
+		"other":        1,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"localhost:9200"}, settings.Output.Hosts)
+	assert.Equal(t, "test", settings.Output.Index)
+	assert.Equal(t, map[string]interface{}{"other": uint64(1)}, settings.Inline)
+
+	require.Len(t, deprecations, 1)
+	d := deprecations[0]
+	assert.Equal(t, "output.host", d.Path)
+	assert.Equal(t, "output.hosts", d.Replacement)
+	assert.Equal(t, "", d.Message)
+	require.NotNil(t, d.Meta)
+	assert.Equal(t, "beat.yml", d.Meta.Source)
+	assert.Equal(t, "setting 'output.host' is deprecated, use 'output.hosts' instead (source:'beat.yml')", d.String())
+}
+
+func TestUnpackAliasNotUsed(t *testing.T) {
+	settings, deprecations, err := unpackDeprecated(t, map[string]interface{}{
+		"output.hosts": []string{"a", "b"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, settings.Output.Hosts)
+	assert.Empty(t, deprecations)
+}
+
+func TestUnpackDeprecated(t *testing.T) {
+	settings, deprecations, err := unpackDeprecated(t, map[string]interface{}{
+		"output.urls":    "localhost:9200",
+		"output.timeout": 30,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"localhost:9200"}, settings.Output.Hosts)
+	assert.Equal(t, 30, settings.Output.Timeout)
+
+	require.Len(t, deprecations, 2)
+	assert.Equal(t, "output.urls", deprecations[0].Path)
+	assert.Equal(t, "output.hosts", deprecations[0].Replacement)
+	assert.Equal(t, "output.timeout", deprecations[1].Path)
+	assert.Equal(t, "", deprecations[1].Replacement)
+	assert.Equal(t, "removed in 9.0", deprecations[1].Message)
+	assert.Equal(t, "setting 'output.timeout' is deprecated: removed in 9.0 (source:'beat.yml')", deprecations[1].String())
+}
+
+func TestUnpackAliasConflict(t *testing.T) {
+	tests := map[string]struct {
+		in      map[string]interface{}
+		message string
+	}{
+		"name and alias": {
+			in: map[string]interface{}{
+				"output.hosts": "a",
+				"output.host":  "b",
+			},
+			message: "conflicting settings 'hosts' and 'host' accessing 'output.hosts' (source:'beat.yml')",
+		},
+		"multiple aliases": {
+			in: map[string]interface{}{
+				"output.host": "a",
+				"output.urls": "b",
+			},
+			message: "conflicting settings 'host' and 'urls' accessing 'output.hosts' (source:'beat.yml')",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := unpackDeprecated(t, test.in)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err.(Error).Reason(), ErrConflict), "unexpected error: %v", err)
+			assert.Equal(t, test.message, err.Error())
+			assert.Equal(t, "output.hosts", ErrorPath(err).String("."))
+		})
+	}
+}
+
+func TestUnpackDeprecatedTag(t *testing.T) {
+	cfg := MustNewFrom(map[string]interface{}{"timeout": 1})
+
+	var settings struct {
+		Timeout int `config:"timeout" obsolete:"use deadline"`
+	}
+	var deprecations []Deprecation
+	err := cfg.Unpack(&settings, DeprecatedTag("obsolete"), OnDeprecated(func(d Deprecation) {
+		deprecations = append(deprecations, d)
+	}))
+	require.NoError(t, err)
+	require.Len(t, deprecations, 1)
+	assert.Equal(t, "use deadline", deprecations[0].Message)
+	assert.Nil(t, deprecations[0].Meta)
+}
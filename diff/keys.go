@@ -36,6 +36,9 @@ const (
 
 	// Keep keys present in both config
 	Keep
+
+	// Modify keys present in both config with different values
+	Modify
 )
 
 func (dt Type) String() string {
@@ -43,6 +46,7 @@ func (dt Type) String() string {
 		"-",
 		"+",
 		" ",
+		"~",
 	}[dt]
 }
 
@@ -64,7 +68,7 @@ func (d Diff) String() string {
 
 // HasChanged returns true if we have remove of added new elements in the graph
 func (d *Diff) HasChanged() bool {
-	if d.HasKeyAdded() || d.HasKeyRemoved() {
+	if d.HasKeyAdded() || d.HasKeyRemoved() || d.HasKeyModified() {
 		return true
 	}
 	return false
@@ -87,6 +91,13 @@ func (d *Diff) HasKeyAdded() bool {
 	return false
 }
 
+// HasKeyModified returns true if the values of keys present in both
+// configurations differ. Only diffs generated by CompareValues report
+// modified keys.
+func (d *Diff) HasKeyModified() bool {
+	return len((*d)[Modify]) > 0
+}
+
 // GoStringer implement the GoStringer interface
 func (d Diff) GoStringer() string {
 	return d.String()
@@ -0,0 +1,394 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	ucfg "github.com/elastic/go-ucfg"
+)
+
+// PatchOp is a single operation of a patch. Patches are similar to JSON Patch
+// (RFC 6902), but operate on dot separated ucfg setting paths.
+type PatchOp struct {
+	Op    string      `json:"op" yaml:"op" config:"op"`
+	Path  string      `json:"path" yaml:"path" config:"path"`
+	Value interface{} `json:"value,omitempty" yaml:"value,omitempty" config:"value"`
+}
+
+// Patch operations.
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+)
+
+var (
+	// ErrUnknownOp indicates a patch operation not being supported.
+	ErrUnknownOp = errors.New("unknown patch operation")
+
+	// ErrPathNotFound indicates the target of a remove or replace operation
+	// not being present in the configuration.
+	ErrPathNotFound = errors.New("path not found")
+)
+
+// Patch creates the list of operations required to turn old into new.
+//
+// Dictionaries and arrays are compared structurally. Settings only present in
+// old are removed and settings only present in new are added as a whole,
+// including empty dictionaries and arrays. Settings changing their type are
+// replaced. Removals are ordered first, starting with the last array element,
+// followed by replacements and additions, such that the operations can be
+// applied in order. Names containing '.' or brackets, and names looking like
+// array indices, are quoted in the paths (e.g. 'labels["app.kubernetes.io/name"]').
+// Values of secret paths are not masked in the patch. IgnorePaths can be used
+// to exclude settings from the patch.
+//
+// Variables are not resolved, unless the ResolveDynamic option is passed via
+// ConfigOptions. Unresolved variables are compared and written to the
+// patch as their expression (e.g. '${VAR}'). Apply restores them as variables
+// if the VarExp option is passed.
+func Patch(old, new *ucfg.Config, opts ...CompareOption) ([]PatchOp, error) {
+	o := compareOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	cfgOpts := append([]ucfg.Option{ucfg.PathSep(".")}, o.configOpts...)
+
+	oldNode, err := newPatchNode(old, cfgOpts)
+	if err != nil {
+		return nil, err
+	}
+	newNode, err := newPatchNode(new, cfgOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var p patcher
+	p.ignore = o.ignore
+	p.diff(ucfg.Path{}, oldNode, newNode)
+
+	sort.SliceStable(p.removes, func(i, j int) bool { return pathLess(p.removes[j].path, p.removes[i].path) })
+	sort.SliceStable(p.adds, func(i, j int) bool { return pathLess(p.adds[i].path, p.adds[j].path) })
+
+	ops := make([]PatchOp, 0, len(p.removes)+len(p.replaces)+len(p.adds))
+	for _, list := range [][]patchEntry{p.removes, p.replaces, p.adds} {
+		for _, e := range list {
+			ops = append(ops, PatchOp{Op: e.op, Path: e.path.String("."), Value: e.value})
+		}
+	}
+	return ops, nil
+}
+
+// patchNode is a setting of a configuration. Dictionaries keep their keys in
+// order, such that patches are deterministic.
+type patchNode struct {
+	kind   ucfg.Kind
+	value  interface{}
+	keys   []string
+	fields map[string]*patchNode
+	elems  []*patchNode
+}
+
+// patchEntry is a patch operation with its structured path, used for
+// ordering the operations.
+type patchEntry struct {
+	op    string
+	path  ucfg.Path
+	value interface{}
+}
+
+type patcher struct {
+	ignore                  []ucfg.PathPattern
+	removes, replaces, adds []patchEntry
+}
+
+func newPatchNode(cfg *ucfg.Config, opts []ucfg.Option) (*patchNode, error) {
+	n := &patchNode{kind: ucfg.KindDict, fields: map[string]*patchNode{}}
+	if cfg.IsArray() && !cfg.IsDict() {
+		n.kind = ucfg.KindArray
+	}
+
+	err := cfg.Walk(func(path string, kind ucfg.Kind, v interface{}, _ *ucfg.Meta) error {
+		child := &patchNode{kind: kind, value: v}
+		if kind == ucfg.KindDict || kind == ucfg.KindArray {
+			sub, err := newPatchNode(v.(*ucfg.Config), opts)
+			if err != nil {
+				return err
+			}
+			child = sub
+		}
+
+		if n.kind == ucfg.KindArray {
+			n.elems = append(n.elems, child)
+		} else {
+			name := fieldName(path)
+			n.keys = append(n.keys, name)
+			n.fields[name] = child
+		}
+		return ucfg.SkipChildren
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// fieldName returns the unquoted name of a direct child reported by Walk.
+func fieldName(path string) string {
+	segments := ucfg.ParsePath(path, ".").Segments()
+	if len(segments) == 1 && segments[0].Kind == ucfg.SegmentName {
+		return segments[0].Name
+	}
+	return path
+}
+
+func (p *patcher) diff(path ucfg.Path, old, new *patchNode) {
+	if len(path.Segments()) > 0 && p.ignored(path) {
+		return
+	}
+
+	switch {
+	case old.kind == ucfg.KindDict && new.kind == ucfg.KindDict:
+		for _, k := range old.keys {
+			if _, exists := new.fields[k]; !exists {
+				p.remove(path.Append(k), old.fields[k])
+			}
+		}
+		for _, k := range new.keys {
+			if o, exists := old.fields[k]; exists {
+				p.diff(path.Append(k), o, new.fields[k])
+			} else {
+				p.add(path.Append(k), new.fields[k])
+			}
+		}
+
+	case old.kind == ucfg.KindArray && new.kind == ucfg.KindArray:
+		for i := len(new.elems); i < len(old.elems); i++ {
+			p.remove(path.Index(i), old.elems[i])
+		}
+		for i, n := range new.elems {
+			if i < len(old.elems) {
+				p.diff(path.Index(i), old.elems[i], n)
+			} else {
+				p.add(path.Index(i), n)
+			}
+		}
+
+	case old.isContainer() || new.isContainer():
+		p.replaces = append(p.replaces, patchEntry{op: OpReplace, path: path, value: new.export()})
+
+	case !equalValues(old.value, new.value):
+		p.replaces = append(p.replaces, patchEntry{op: OpReplace, path: path, value: new.value})
+	}
+}
+
+// remove removes the setting at path. If the setting holds ignored settings,
+// only the settings not being ignored are removed.
+func (p *patcher) remove(path ucfg.Path, n *patchNode) {
+	if p.ignored(path) {
+		return
+	}
+	if !p.hasIgnored(path, n) {
+		p.removes = append(p.removes, patchEntry{op: OpRemove, path: path})
+		return
+	}
+	n.each(path, func(path ucfg.Path, child *patchNode) { p.remove(path, child) })
+}
+
+// add adds the setting at path. If the setting holds ignored settings, an
+// empty dictionary or array is added first, followed by the settings not
+// being ignored.
+func (p *patcher) add(path ucfg.Path, n *patchNode) {
+	if p.ignored(path) {
+		return
+	}
+	if !p.hasIgnored(path, n) {
+		p.adds = append(p.adds, patchEntry{op: OpAdd, path: path, value: n.export()})
+		return
+	}
+
+	empty := &patchNode{kind: n.kind}
+	p.adds = append(p.adds, patchEntry{op: OpAdd, path: path, value: empty.export()})
+	n.each(path, func(path ucfg.Path, child *patchNode) { p.add(path, child) })
+}
+
+func (p *patcher) ignored(path ucfg.Path) bool {
+	return len(p.ignore) > 0 && matchAny(p.ignore, path.String("."))
+}
+
+func (p *patcher) hasIgnored(path ucfg.Path, n *patchNode) bool {
+	if len(p.ignore) == 0 {
+		return false
+	}
+
+	found := false
+	n.each(path, func(path ucfg.Path, child *patchNode) {
+		found = found || p.ignored(path) || p.hasIgnored(path, child)
+	})
+	return found
+}
+
+func (n *patchNode) isContainer() bool {
+	return n.kind == ucfg.KindDict || n.kind == ucfg.KindArray
+}
+
+// each calls fn for all direct children of n.
+func (n *patchNode) each(path ucfg.Path, fn func(ucfg.Path, *patchNode)) {
+	for _, k := range n.keys {
+		fn(path.Append(k), n.fields[k])
+	}
+	for i, elem := range n.elems {
+		fn(path.Index(i), elem)
+	}
+}
+
+// export converts n into plain Go values. Empty dictionaries and arrays are
+// kept.
+func (n *patchNode) export() interface{} {
+	switch n.kind {
+	case ucfg.KindDict:
+		m := make(map[string]interface{}, len(n.keys))
+		for _, k := range n.keys {
+			m[k] = n.fields[k].export()
+		}
+		return m
+	case ucfg.KindArray:
+		arr := make([]interface{}, len(n.elems))
+		for i, elem := range n.elems {
+			arr[i] = elem.export()
+		}
+		return arr
+	default:
+		return n.value
+	}
+}
+
+// Apply applies the patch operations in order to cfg. Apply stops at the
+// first failing operation, leaving the operations applied so far in cfg.
+//
+// Values are normalized and set via SetAt, such that variable expressions are
+// parsed if the VarExp option is passed. Objects and arrays, including empty
+// ones, are set element by element, keeping keys containing '.' verbatim.
+// Paths are always separated by '.'. The options are passed to all setters.
+func Apply(cfg *ucfg.Config, patch []PatchOp, opts ...ucfg.Option) error {
+	opts = append([]ucfg.Option{ucfg.PathSep(".")}, opts...)
+
+	for _, op := range patch {
+		if err := applyOp(cfg, op, opts); err != nil {
+			return fmt.Errorf("failed to apply '%v' on '%v': %w", op.Op, op.Path, err)
+		}
+	}
+	return nil
+}
+
+func applyOp(cfg *ucfg.Config, op PatchOp, opts []ucfg.Option) error {
+	path := ucfg.ParsePath(op.Path, ".")
+
+	switch op.Op {
+	case OpAdd:
+		return setValue(cfg, path, op.Value, opts)
+
+	case OpReplace:
+		has, err := cfg.HasAt(path, opts...)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return ErrPathNotFound
+		}
+		return setValue(cfg, path, op.Value, opts)
+
+	case OpRemove:
+		removed, err := cfg.RemoveAt(path, opts...)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			return ErrPathNotFound
+		}
+		return nil
+
+	default:
+		return ErrUnknownOp
+	}
+}
+
+// setValue sets v at path. Objects and arrays are set element by element,
+// such that keys containing the path separator are not split.
+func setValue(cfg *ucfg.Config, path ucfg.Path, v interface{}, opts []ucfg.Option) error {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if err := ucfg.SetAt(cfg, path, map[string]interface{}{}, opts...); err != nil {
+			return err
+		}
+
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := setValue(cfg, path.Append(k), val[k], opts); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case []interface{}:
+		if err := ucfg.SetAt(cfg, path, []interface{}{}, opts...); err != nil {
+			return err
+		}
+		for i, elem := range val {
+			if err := setValue(cfg, path.Index(i), elem, opts); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		return ucfg.SetAt(cfg, path, val, opts...)
+	}
+}
+
+// pathLess compares paths segment by segment. Array indices are compared
+// numerically, such that 'a.2' sorts before 'a.10'.
+func pathLess(a, b ucfg.Path) bool {
+	as, bs := a.Segments(), b.Segments()
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+
+		if as[i].Kind == ucfg.SegmentIndex && bs[i].Kind == ucfg.SegmentIndex {
+			return as[i].Index < bs[i].Index
+		}
+		return segmentString(as[i]) < segmentString(bs[i])
+	}
+	return len(as) < len(bs)
+}
+
+func segmentString(s ucfg.PathSegment) string {
+	if s.Kind == ucfg.SegmentIndex {
+		return strconv.Itoa(s.Index)
+	}
+	return s.Name
+}
@@ -0,0 +1,301 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package diff
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ucfg "github.com/elastic/go-ucfg"
+)
+
+func TestPatchApply(t *testing.T) {
+	cases := map[string]struct {
+		old, new map[string]interface{}
+	}{
+		"modify values": {
+			old: map[string]interface{}{"a": 1, "b": "x", "c": true},
+			new: map[string]interface{}{"a": 2, "b": "y", "c": true},
+		},
+		"add and remove": {
+			old: map[string]interface{}{"a.b": 1, "removed": "x"},
+			new: map[string]interface{}{"a.b": 1, "a.c": 2.5, "added": "y"},
+		},
+		"shrink array": {
+			old: map[string]interface{}{"hosts": []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"}},
+			new: map[string]interface{}{"hosts": []string{"a"}},
+		},
+		"grow array": {
+			old: map[string]interface{}{"hosts": []string{"a"}},
+			new: map[string]interface{}{"hosts": []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"}},
+		},
+		"primitive to object": {
+			old: map[string]interface{}{"output": "console"},
+			new: map[string]interface{}{"output.console.pretty": true},
+		},
+		"object to primitive": {
+			old: map[string]interface{}{"output.console.pretty": true},
+			new: map[string]interface{}{"output": "console"},
+		},
+	}
+
+	for name, test := range cases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			old, err := ucfg.NewFrom(test.old, opts...)
+			require.NoError(t, err)
+			new, err := ucfg.NewFrom(test.new, opts...)
+			require.NoError(t, err)
+
+			patch, err := Patch(old, new)
+			require.NoError(t, err)
+			require.NotEmpty(t, patch)
+
+			// ship patch as JSON
+			raw, err := json.Marshal(patch)
+			require.NoError(t, err)
+			var decoded []PatchOp
+			require.NoError(t, json.Unmarshal(raw, &decoded))
+
+			require.NoError(t, Apply(old, decoded))
+
+			changes, err := CompareValues(old, new)
+			require.NoError(t, err)
+			assert.False(t, changes.HasChanged(), "unexpected changes:\n%v", changes)
+		})
+	}
+}
+
+func TestPatchRoundTrip(t *testing.T) {
+	type m = map[string]interface{}
+	type a = []interface{}
+
+	cases := map[string]struct {
+		old, new m
+	}{
+		"nested": {
+			old: m{"a": m{"b": m{"c": 1, "d": "x"}}, "e": a{m{"f": 1}, m{"g": 2}}},
+			new: m{"a": m{"b": m{"c": 2}, "h": true}, "e": a{m{"f": 1, "i": 3}}},
+		},
+		"emptied dictionary": {
+			old: m{"a": m{"b": 1}, "k": 1},
+			new: m{"k": m{}},
+		},
+		"emptied nested dictionary": {
+			old: m{"a": m{"b": m{"c": 1}, "d": 2}},
+			new: m{"a": m{"b": m{}, "d": 2}},
+		},
+		"emptied array": {
+			old: m{"hosts": a{"a", "b"}, "nested": a{a{1, 2}}},
+			new: m{"hosts": a{}, "nested": a{a{}}},
+		},
+		"add empty containers": {
+			old: m{},
+			new: m{"d": m{}, "arr": a{}, "x": m{"y": a{m{}}}},
+		},
+		"dictionary to primitive": {
+			old: m{"a": m{"b": 1}},
+			new: m{"a": "b"},
+		},
+		"primitive to dictionary": {
+			old: m{"a": "b"},
+			new: m{"a": m{"b": 1}},
+		},
+		"array to dictionary": {
+			old: m{"a": a{1, 2}},
+			new: m{"a": m{"b": 1}},
+		},
+		"dictionary to array": {
+			old: m{"a": m{"b": 1}},
+			new: m{"a": a{1, m{}}},
+		},
+		"value to null": {
+			old: m{"a": 1, "b": nil},
+			new: m{"a": nil, "b": "x"},
+		},
+	}
+
+	for name, test := range cases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			old, err := ucfg.NewFrom(test.old, opts...)
+			require.NoError(t, err)
+			new, err := ucfg.NewFrom(test.new, opts...)
+			require.NoError(t, err)
+
+			patch, err := Patch(old, new)
+			require.NoError(t, err)
+
+			require.NoError(t, Apply(old, patch))
+			assert.True(t, old.Equal(new), "patch %+v does not reproduce the target", patch)
+		})
+	}
+}
+
+func TestPatchRoundTripQuotedKeys(t *testing.T) {
+	type m = map[string]interface{}
+
+	// numeric keys are only kept as names with EnableNumKeys
+	cfgOpts := []ucfg.Option{ucfg.EnableNumKeys(true)}
+	old, err := ucfg.NewFrom(m{
+		"labels": m{
+			"app.kubernetes.io/name": "beat",
+			"0":                      "zero",
+			"-1":                     "last",
+			"removed.key":            true,
+		},
+	}, cfgOpts...)
+	require.NoError(t, err)
+	new, err := ucfg.NewFrom(m{
+		"labels": m{
+			"app.kubernetes.io/name": "agent",
+			"0":                      "zero",
+			"-1":                     "first",
+			"+":                      "append",
+			"1:3":                    "slice",
+			"team[0]":                m{"a.b": 1},
+		},
+	}, cfgOpts...)
+	require.NoError(t, err)
+
+	patch, err := Patch(old, new)
+	require.NoError(t, err)
+	assert.Equal(t, []PatchOp{
+		{Op: OpRemove, Path: `labels["removed.key"]`},
+		{Op: OpReplace, Path: `labels["-1"]`, Value: "first"},
+		{Op: OpReplace, Path: `labels["app.kubernetes.io/name"]`, Value: "agent"},
+		{Op: OpAdd, Path: `labels["+"]`, Value: "append"},
+		{Op: OpAdd, Path: `labels["1:3"]`, Value: "slice"},
+		{Op: OpAdd, Path: `labels["team[0]"]`, Value: map[string]interface{}{"a.b": uint64(1)}},
+	}, patch)
+
+	require.NoError(t, Apply(old, patch, cfgOpts...))
+	assert.True(t, old.Equal(new), "patch %+v does not reproduce the target", patch)
+}
+
+func TestPatchVariables(t *testing.T) {
+	cfgOpts := []ucfg.Option{ucfg.PathSep("."), ucfg.VarExp}
+	old, err := ucfg.NewFrom(map[string]interface{}{
+		"port": 80,
+		"host": "localhost:${port}",
+	}, cfgOpts...)
+	require.NoError(t, err)
+	new, err := ucfg.NewFrom(map[string]interface{}{
+		"port": 8080,
+		"host": "localhost:${port}",
+		"url":  "http://${host}",
+	}, cfgOpts...)
+	require.NoError(t, err)
+
+	resolved, err := Patch(old, new, ConfigOptions(ucfg.ResolveDynamic))
+	require.NoError(t, err)
+	assert.Equal(t, []PatchOp{
+		{Op: OpReplace, Path: "host", Value: "localhost:8080"},
+		{Op: OpReplace, Path: "port", Value: uint64(8080)},
+		{Op: OpAdd, Path: "url", Value: "http://localhost:8080"},
+	}, resolved)
+
+	patch, err := Patch(old, new)
+	require.NoError(t, err)
+	assert.Equal(t, []PatchOp{
+		{Op: OpReplace, Path: "port", Value: uint64(8080)},
+		{Op: OpAdd, Path: "url", Value: "http://${host}"},
+	}, patch)
+
+	require.NoError(t, Apply(old, patch, cfgOpts...))
+	assert.True(t, old.Equal(new), "patch %+v does not reproduce the target", patch)
+
+	// host still references port
+	require.NoError(t, old.SetInt("port", -1, 9200))
+	url, err := old.String("url", -1)
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:9200", url)
+
+}
+
+func TestPatchOps(t *testing.T) {
+	old, err := ucfg.NewFrom(map[string]interface{}{
+		"a":     1,
+		"hosts": []string{"a", "b", "c"},
+	}, opts...)
+	require.NoError(t, err)
+
+	new, err := ucfg.NewFrom(map[string]interface{}{
+		"a":     2,
+		"b":     "x",
+		"hosts": []string{"a"},
+	}, opts...)
+	require.NoError(t, err)
+
+	patch, err := Patch(old, new)
+	require.NoError(t, err)
+	assert.Equal(t, []PatchOp{
+		{Op: OpRemove, Path: "hosts.2"},
+		{Op: OpRemove, Path: "hosts.1"},
+		{Op: OpReplace, Path: "a", Value: uint64(2)},
+		{Op: OpAdd, Path: "b", Value: "x"},
+	}, patch)
+}
+
+func TestApplyErrors(t *testing.T) {
+	cfg, err := ucfg.NewFrom(map[string]interface{}{"a": 1})
+	require.NoError(t, err)
+
+	err = Apply(cfg, []PatchOp{{Op: OpRemove, Path: "b"}})
+	assert.True(t, errors.Is(err, ErrPathNotFound), "unexpected error: %v", err)
+
+	err = Apply(cfg, []PatchOp{{Op: OpReplace, Path: "b", Value: 1}})
+	assert.True(t, errors.Is(err, ErrPathNotFound), "unexpected error: %v", err)
+
+	err = Apply(cfg, []PatchOp{{Op: "move", Path: "a"}})
+	assert.True(t, errors.Is(err, ErrUnknownOp), "unexpected error: %v", err)
+
+	require.NoError(t, Apply(cfg, []PatchOp{
+		{Op: OpAdd, Path: "obj", Value: map[string]interface{}{"x": []interface{}{1, 2}}},
+		{Op: OpReplace, Path: "a", Value: nil},
+	}))
+
+	x, err := cfg.Int("obj.x", 1, opts...)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), x)
+}
+
+func TestPatchIgnorePaths(t *testing.T) {
+	old, err := ucfg.NewFrom(map[string]interface{}{
+		"a":      map[string]interface{}{"b": 1, "secret": "x"},
+		"logger": map[string]interface{}{"level": "info"},
+	}, opts...)
+	require.NoError(t, err)
+
+	new, err := ucfg.NewFrom(map[string]interface{}{
+		"c": map[string]interface{}{"d": 2, "secret": "y"},
+	}, opts...)
+	require.NoError(t, err)
+
+	patch, err := Patch(old, new, IgnorePaths("**.secret", "logger"))
+	require.NoError(t, err)
+	assert.Equal(t, []PatchOp{
+		{Op: OpRemove, Path: "a.b"},
+		{Op: OpAdd, Path: "c", Value: map[string]interface{}{}},
+		{Op: OpAdd, Path: "c.d", Value: uint64(2)},
+	}, patch)
+}
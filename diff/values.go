@@ -0,0 +1,298 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	ucfg "github.com/elastic/go-ucfg"
+)
+
+// Change describes the difference of a single setting between two
+// configurations.
+type Change struct {
+	Type Type
+	Key  string
+
+	Old interface{}
+	New interface{}
+
+	OldMeta *ucfg.Meta
+	NewMeta *ucfg.Meta
+
+	// Secret is set if the key matches a secret path. Secret values are masked
+	// when formatting the change.
+	Secret bool
+}
+
+// Changes is a list of changes ordered by key.
+type Changes []Change
+
+// CompareOption configures CompareValues.
+type CompareOption func(*compareOptions)
+
+type compareOptions struct {
+	configOpts []ucfg.Option
+	ignore     []ucfg.PathPattern
+	secrets    []ucfg.PathPattern
+}
+
+const secretMask = "<hidden>"
+
+// DefaultSecretPaths lists the patterns of keys masked by default when
+// formatting changes.
+var DefaultSecretPaths = []string{
+	"**.*password*",
+	"**.*passwd*",
+	"**.*secret*",
+	"**.*token*",
+	"**.api_key",
+	"**.*apikey*",
+}
+
+// ConfigOptions sets the options used to access the configurations (e.g.
+// Env, Resolve, or ResolveEnv for resolving variables).
+func ConfigOptions(opts ...ucfg.Option) CompareOption {
+	return func(o *compareOptions) {
+		o.configOpts = append(o.configOpts, opts...)
+	}
+}
+
+// IgnorePaths excludes keys matching any of the patterns from the comparison.
+// Patterns are dot separated keys. The wildcard '*' matches exactly one
+// segment of the key, shell patterns like 'ssl.*_key' match within a single
+// segment and '**' matches any number of segments. Names containing '.' are
+// quoted in keys and patterns (e.g. 'labels["app.kubernetes.io/name"]').
+// Invalid patterns match no keys.
+func IgnorePaths(patterns ...string) CompareOption {
+	return IgnorePathPatterns(compilePatterns(patterns)...)
+}
+
+// IgnorePathPatterns excludes keys matching any of the path patterns from the
+// comparison.
+func IgnorePathPatterns(patterns ...ucfg.PathPattern) CompareOption {
+	return func(o *compareOptions) {
+		o.ignore = append(o.ignore, patterns...)
+	}
+}
+
+// SecretPaths adds patterns of keys to mask when formatting changes. See
+// IgnorePaths for the pattern syntax.
+func SecretPaths(patterns ...string) CompareOption {
+	return SecretPathPatterns(compilePatterns(patterns)...)
+}
+
+// SecretPathPatterns adds path patterns of keys to mask when formatting
+// changes.
+func SecretPathPatterns(patterns ...ucfg.PathPattern) CompareOption {
+	return func(o *compareOptions) {
+		o.secrets = append(o.secrets, patterns...)
+	}
+}
+
+// CompareValues compares the keys and resolved values of two configurations.
+// Keys are reported as Modify if present in both configurations with
+// different values. Names containing '.' or brackets are quoted in the keys,
+// like the keys returned by FlattenedKeys with PathSep.
+func CompareValues(old, new *ucfg.Config, opts ...CompareOption) (Changes, error) {
+	o := compareOptions{secrets: compilePatterns(DefaultSecretPaths)}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	cfgOpts := append([]ucfg.Option{ucfg.PathSep(".")}, o.configOpts...)
+
+	oldKeys := old.FlattenedKeys(cfgOpts...)
+	newKeys := new.FlattenedKeys(cfgOpts...)
+
+	changes := make(map[string]*Change, len(oldKeys))
+	for _, k := range oldKeys {
+		if matchAny(o.ignore, k) {
+			continue
+		}
+
+		v, meta, err := lookup(old, k, cfgOpts)
+		if err != nil {
+			return nil, err
+		}
+		changes[k] = &Change{Type: Remove, Key: k, Old: v, OldMeta: meta}
+	}
+
+	for _, k := range newKeys {
+		if matchAny(o.ignore, k) {
+			continue
+		}
+
+		v, meta, err := lookup(new, k, cfgOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		change, exists := changes[k]
+		if !exists {
+			changes[k] = &Change{Type: Add, Key: k, New: v, NewMeta: meta}
+			continue
+		}
+
+		change.New, change.NewMeta = v, meta
+		if equalValues(change.Old, v) {
+			change.Type = Keep
+		} else {
+			change.Type = Modify
+		}
+	}
+
+	result := make(Changes, 0, len(changes))
+	for k, change := range changes {
+		change.Secret = matchAny(o.secrets, k)
+		result = append(result, *change)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result, nil
+}
+
+func lookup(cfg *ucfg.Config, key string, opts []ucfg.Option) (interface{}, *ucfg.Meta, error) {
+	v, err := ucfg.Get[interface{}](cfg, key, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	meta, err := cfg.MetaOf(key, -1, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return v, meta, nil
+}
+
+// HasChanged returns true if any key has been added, removed or modified.
+func (c Changes) HasChanged() bool {
+	for _, change := range c {
+		if change.Type != Keep {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the changes of the given types only.
+func (c Changes) Filter(types ...Type) Changes {
+	var filtered Changes
+	for _, change := range c {
+		for _, t := range types {
+			if change.Type == t {
+				filtered = append(filtered, change)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// Diff returns the changed keys grouped by change type.
+func (c Changes) Diff() Diff {
+	d := make(Diff)
+	for _, change := range c {
+		d[change.Type] = append(d[change.Type], change.Key)
+	}
+	return d
+}
+
+// String formats the changes like a unified diff. Modified keys are reported
+// as removed line with the old value, followed by an added line with the new
+// value. Values of secret keys are masked.
+func (c Changes) String() string {
+	lines := []string{"--- old", "+++ new"}
+	for _, change := range c {
+		switch change.Type {
+		case Remove:
+			lines = append(lines, change.line(Remove, change.Old))
+		case Add:
+			lines = append(lines, change.line(Add, change.New))
+		case Keep:
+			lines = append(lines, change.line(Keep, change.New))
+		case Modify:
+			lines = append(lines, change.line(Remove, change.Old), change.line(Add, change.New))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (c Change) line(t Type, v interface{}) string {
+	return fmt.Sprintf("%v %v: %v", t, c.Key, c.format(v))
+}
+
+func (c Change) format(v interface{}) string {
+	switch {
+	case c.Secret:
+		return secretMask
+	case v == nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// equalValues compares two primitive values. Numbers are compared by value,
+// such that int64, uint64 and float64 values representing the same number
+// are equal.
+func equalValues(a, b interface{}) bool {
+	switch x := a.(type) {
+	case int64:
+		switch y := b.(type) {
+		case uint64:
+			return x >= 0 && uint64(x) == y
+		case float64:
+			return float64(x) == y
+		}
+	case uint64:
+		switch y := b.(type) {
+		case int64:
+			return y >= 0 && uint64(y) == x
+		case float64:
+			return float64(x) == y
+		}
+	case float64:
+		switch y := b.(type) {
+		case int64:
+			return float64(y) == x
+		case uint64:
+			return float64(y) == x
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func matchAny(patterns []ucfg.PathPattern, key string) bool {
+	for _, pattern := range patterns {
+		if pattern.Match(key) {
+			return true
+		}
+	}
+	return false
+}
+
+func compilePatterns(patterns []string) []ucfg.PathPattern {
+	compiled := make([]ucfg.PathPattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		if p, err := ucfg.CompilePathPattern(pattern); err == nil {
+			compiled = append(compiled, p)
+		}
+	}
+	return compiled
+}
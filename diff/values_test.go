@@ -0,0 +1,180 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ucfg "github.com/elastic/go-ucfg"
+)
+
+func TestCompareValues(t *testing.T) {
+	old, err := ucfg.NewFrom(map[string]interface{}{
+		"output.hosts":    []string{"a"},
+		"output.password": "old",
+		"output.timeout":  10,
+		"name":            "beat",
+		"removed":         true,
+	}, ucfg.PathSep("."), ucfg.MetaData(ucfg.Meta{Source: "old.yml"}))
+	require.NoError(t, err)
+
+	new, err := ucfg.NewFrom(map[string]interface{}{
+		"output.hosts":    []string{"b"},
+		"output.password": "new",
+		"output.timeout":  uint64(10),
+		"name":            "beat",
+		"added":           1.5,
+	}, ucfg.PathSep("."), ucfg.MetaData(ucfg.Meta{Source: "new.yml"}))
+	require.NoError(t, err)
+
+	changes, err := CompareValues(old, new)
+	require.NoError(t, err)
+	assert.True(t, changes.HasChanged())
+
+	d := changes.Diff()
+	assert.Equal(t, []string{"added"}, d[Add])
+	assert.Equal(t, []string{"removed"}, d[Remove])
+	assert.Equal(t, []string{"name", "output.timeout"}, d[Keep])
+	assert.Equal(t, []string{"output.hosts.0", "output.password"}, d[Modify])
+	assert.True(t, d.HasChanged())
+	assert.True(t, d.HasKeyModified())
+
+	modified := changes.Filter(Modify)
+	require.Len(t, modified, 2)
+	assert.Equal(t, Change{
+		Type:    Modify,
+		Key:     "output.hosts.0",
+		Old:     "a",
+		New:     "b",
+		OldMeta: &ucfg.Meta{Source: "old.yml"},
+		NewMeta: &ucfg.Meta{Source: "new.yml"},
+	}, modified[0])
+	assert.True(t, modified[1].Secret)
+
+	expected := `--- old
++++ new
++ added: 1.5
+  name: beat
+- output.hosts.0: a
++ output.hosts.0: b
+- output.password: <hidden>
++ output.password: <hidden>
+  output.timeout: 10
+- removed: true`
+	assert.Equal(t, expected, changes.String())
+}
+
+func TestCompareValuesUnchanged(t *testing.T) {
+	cfg, err := ucfg.NewFrom(map[string]interface{}{
+		"a.b": []int{1, 2},
+	}, ucfg.PathSep("."))
+	require.NoError(t, err)
+
+	changes, err := CompareValues(cfg, cfg)
+	require.NoError(t, err)
+	assert.False(t, changes.HasChanged())
+	assert.Len(t, changes, 2)
+}
+
+func TestCompareValuesQuotedKeys(t *testing.T) {
+	old := ucfg.MustNewFrom(map[string]interface{}{
+		"labels": map[string]interface{}{
+			"app.kubernetes.io/name": "beat",
+			"db.password":            "a",
+			"removed.key":            1,
+		},
+	})
+	new := ucfg.MustNewFrom(map[string]interface{}{
+		"labels": map[string]interface{}{
+			"app.kubernetes.io/name": "agent",
+			"db.password":            "b",
+			"team[0]":                "obs",
+		},
+	})
+
+	changes, err := CompareValues(old, new, IgnorePaths(`labels["removed.key"]`))
+	require.NoError(t, err)
+
+	d := changes.Diff()
+	assert.Equal(t, []string{`labels["team[0]"]`}, d[Add])
+	assert.Equal(t, []string{`labels["app.kubernetes.io/name"]`, `labels["db.password"]`}, d[Modify])
+	assert.Empty(t, d[Remove])
+	for _, change := range changes {
+		assert.Equal(t, change.Key == `labels["db.password"]`, change.Secret, change.Key)
+	}
+}
+
+func TestCompareValuesOptions(t *testing.T) {
+	old, err := ucfg.NewFrom(map[string]interface{}{
+		"logging.level":  "info",
+		"output.host":    "${host}",
+		"output.ssl.key": "a",
+		"token":          "x",
+	}, ucfg.PathSep("."), ucfg.VarExp)
+	require.NoError(t, err)
+
+	new, err := ucfg.NewFrom(map[string]interface{}{
+		"logging.level":  "debug",
+		"output.host":    "localhost",
+		"output.ssl.key": "b",
+		"token":          "y",
+	}, ucfg.PathSep("."))
+	require.NoError(t, err)
+
+	t.Setenv("host", "localhost")
+	changes, err := CompareValues(old, new,
+		ConfigOptions(ucfg.ResolveEnv),
+		IgnorePaths("logging.**"),
+		SecretPathPatterns(ucfg.MustCompilePathPattern("**.ssl.*")),
+	)
+	require.NoError(t, err)
+
+	d := changes.Diff()
+	assert.Equal(t, []string{"output.host"}, d[Keep])
+	assert.Equal(t, []string{"output.ssl.key", "token"}, d[Modify])
+	for _, change := range changes.Filter(Modify) {
+		assert.True(t, change.Secret, change.Key)
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	cases := []struct {
+		pattern, key string
+		match        bool
+	}{
+		{"a.b", "a.b", true},
+		{"a.*", "a.b", true},
+		{"a.*", "a.b.c", false},
+		{"a.**", "a.b.c", true},
+		{"**.password", "password", true},
+		{"**.password", "output.es.password", true},
+		{"**.*pass*", "output.es.passwd", true},
+		{"output.*.hosts", "output.es.hosts", true},
+		{"output.*.hosts", "output.hosts", false},
+		{"**.*password*", `auth["db.password"]`, true},
+		{`labels["a.b"]`, `labels["a.b"]`, true},
+		{`labels["a.b"]`, "labels.a.b", false},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.match, matchAny(compilePatterns([]string{c.pattern}), c.key), "%v ~ %v", c.pattern, c.key)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"reflect"
 	"runtime/debug"
+	"strings"
 )
 
 // Error type returned by all public functions in go-ucfg.
@@ -45,11 +46,27 @@ type Error interface {
 	Trace() string
 }
 
+// ErrorPath returns the path of the config element err occurred for. Paths of
+// errors not created by go-ucfg are parsed from the '.' separated Path(). The
+// empty path is returned if err does not wrap an Error.
+func ErrorPath(err error) Path {
+	var structured interface{ StructuredPath() Path }
+	if errors.As(err, &structured) {
+		return structured.StructuredPath()
+	}
+
+	var ucfgErr Error
+	if errors.As(err, &ucfgErr) {
+		return ParsePath(ucfgErr.Path(), ".")
+	}
+	return Path{}
+}
+
 type baseError struct {
 	reason  error
 	class   error
 	message string
-	path    string
+	path    Path
 }
 
 type criticalError struct {
@@ -97,6 +114,44 @@ var (
 
 	ErrRequired = errors.New("missing required field")
 
+	ErrExcluded = errors.New("field must not be set")
+
+	ErrExactlyOne = errors.New("exactly one field must be set")
+
+	ErrNotOneOf = errors.New("value not allowed")
+
+	ErrPatternMismatch = errors.New("value does not match pattern")
+
+	ErrInvalidURL = errors.New("invalid URL")
+
+	ErrInvalidHostPort = errors.New("invalid host:port")
+
+	ErrInvalidHostname = errors.New("invalid hostname")
+
+	ErrInvalidIP = errors.New("invalid IP address")
+
+	ErrInvalidCIDR = errors.New("invalid CIDR")
+
+	ErrInvalidPort = errors.New("invalid port")
+
+	ErrInvalidTime = errors.New("invalid RFC3339 timestamp")
+
+	ErrInvalidLocation = errors.New("invalid time zone location")
+
+	ErrInvalidFileMode = errors.New("invalid file mode")
+
+	ErrInvalidByteSize = errors.New("invalid byte size")
+
+	ErrTooShort = errors.New("value too short")
+
+	ErrTooLong = errors.New("value too long")
+
+	ErrNotUnique = errors.New("duplicate values")
+
+	ErrFileNotFound = errors.New("file does not exist")
+
+	ErrDirNotFound = errors.New("directory does not exist")
+
 	ErrEmpty = errors.New("empty field")
 
 	ErrArrayEmpty = errors.New("empty array")
@@ -106,6 +161,22 @@ var (
 	ErrRegexEmpty = errors.New("regex value is not set")
 
 	ErrStringEmpty = errors.New("string value is not set")
+
+	ErrTypeNoInterface = errors.New("type is no interface")
+
+	ErrDuplicateType = errors.New("type already registered")
+
+	ErrUnknownType = errors.New("unknown type")
+
+	ErrTypeKeyCount = errors.New("exactly one key required")
+
+	ErrNilInstance = errors.New("constructor returned nil")
+
+	ErrConflict = errors.New("conflicting values")
+
+	ErrInvalidPathPattern = errors.New("invalid path pattern")
+
+	ErrInvalidQuery = errors.New("invalid query")
 )
 
 // Error Classes
@@ -119,9 +190,13 @@ func (e baseError) Error() string { return e.Message() }
 func (e baseError) Reason() error { return e.reason }
 func (e baseError) Class() error  { return e.class }
 func (e baseError) Trace() string { return "" }
-func (e baseError) Path() string  { return e.path }
+func (e baseError) Path() string  { return e.path.String(".") }
 func (e baseError) Unwrap() error { return e.reason }
 
+// StructuredPath returns the path of the config element the error occurred
+// for. Use ErrorPath to get the path of any error.
+func (e baseError) StructuredPath() Path { return e.path }
+
 func (e baseError) Message() string {
 	if e.message == "" {
 		return e.reason.Error()
@@ -158,13 +233,13 @@ func raiseCritical(reason error, message string) Error {
 		message = fmt.Sprintf("(assert) %v", message)
 	}
 	return criticalError{
-		baseError{reason, ErrImplementation, message, ""},
+		baseError{reason, ErrImplementation, message, Path{}},
 		string(debug.Stack()),
 	}
 }
 
-func raisePathErr(reason error, meta *Meta, message, path string) Error {
-	message = messagePath(reason, meta, message, path)
+func raisePathErr(reason error, meta *Meta, message string, path Path) Error {
+	message = messagePath(reason, meta, message, path.String("."))
 	return baseError{reason, ErrConfig, message, path}
 }
 
@@ -191,7 +266,7 @@ func messagePath(reason error, meta *Meta, message, path string) string {
 }
 
 func raiseDuplicateKey(cfg *Config, name string) Error {
-	return raisePathErr(ErrDuplicateKey, cfg.metadata, "", cfg.PathOf(name, "."))
+	return raisePathErr(ErrDuplicateKey, cfg.metadata, "", cfg.ctx.structuredPathOf(name))
 }
 
 func raiseCyclicErr(field string) Error {
@@ -210,12 +285,21 @@ func raiseMissing(c *Config, field string) Error {
 }
 
 func raiseMissingMsg(c *Config, field string, message string) Error {
-	return raisePathErr(ErrMissing, c.metadata, message, c.PathOf(field, "."))
+	return raisePathErr(ErrMissing, c.metadata, message, c.ctx.structuredPathOf(field))
+}
+
+// raiseMissingPath reports the setting at the path p within c as missing.
+func raiseMissingPath(c *Config, p cfgPath) Error {
+	path := c.ctx.structuredPath()
+	for _, f := range p.fields {
+		path = path.with(f)
+	}
+	return raisePathErr(ErrMissing, c.metadata, "", path)
 }
 
 func raiseMissingArr(ctx context, meta *Meta, idx int) Error {
 	message := fmt.Sprintf("no value in array at %v", idx)
-	return raisePathErr(ErrMissing, meta, message, ctx.path("."))
+	return raisePathErr(ErrMissing, meta, message, ctx.structuredPath())
 }
 
 func raiseIndexOutOfBounds(opts *options, value value, idx int) Error {
@@ -223,7 +307,7 @@ func raiseIndexOutOfBounds(opts *options, value value, idx int) Error {
 	ctx := value.Context()
 	len, _ := value.Len(opts)
 	message := fmt.Sprintf("index '%v' out of range (length=%v)", idx, len)
-	return raisePathErr(reason, value.meta(), message, ctx.path("."))
+	return raisePathErr(reason, value.meta(), message, ctx.structuredPath())
 }
 
 func raiseInvalidTopLevelType(v interface{}, meta *Meta) Error {
@@ -238,28 +322,28 @@ func raiseKeyInvalidTypeUnpack(t reflect.Type, from *Config) Error {
 	ctx := from.ctx
 	reason := ErrKeyTypeNotString
 	message := fmt.Sprintf("string key required when unpacking into '%v'", t)
-	return raiseCritical(reason, messagePath(reason, from.metadata, message, ctx.path(".")))
+	return raiseCritical(reason, messagePath(reason, from.metadata, message, ctx.quotedPath()))
 }
 
 func raiseKeyInvalidTypeMerge(cfg *Config, t reflect.Type) Error {
 	ctx := cfg.ctx
 	reason := ErrKeyTypeNotString
 	message := fmt.Sprintf("string key required when merging into '%v'", t)
-	return raiseCritical(reason, messagePath(reason, cfg.metadata, message, ctx.path(".")))
+	return raiseCritical(reason, messagePath(reason, cfg.metadata, message, ctx.quotedPath()))
 }
 
 func raiseSquashNeedsObject(cfg *Config, opts *options, f string, t reflect.Type) Error {
 	reason := ErrTypeMismatch
 	message := fmt.Sprintf("require map or struct when squash merging '%v' (%v)", f, t)
 
-	return raiseCritical(reason, messagePath(reason, opts.meta, message, cfg.Path(".")))
+	return raiseCritical(reason, messagePath(reason, opts.meta, message, cfg.ctx.quotedPath()))
 }
 
 func raiseInlineNeedsObject(cfg *Config, f string, t reflect.Type) Error {
 	reason := ErrTypeMismatch
 	message := fmt.Sprintf("require map or struct when inling '%v' (%v)", f, t)
 	return raiseCritical(reason,
-		messagePath(reason, cfg.metadata, message, cfg.Path(".")))
+		messagePath(reason, cfg.metadata, message, cfg.ctx.quotedPath()))
 }
 
 func raiseUnsupportedInputType(ctx context, meta *Meta, v reflect.Value) Error {
@@ -267,12 +351,12 @@ func raiseUnsupportedInputType(ctx context, meta *Meta, v reflect.Value) Error {
 	message := fmt.Sprintf("unspported input type (%v) with value '%#v'",
 		v.Type(), v)
 
-	return raiseCritical(reason, messagePath(reason, meta, message, ctx.path(".")))
+	return raiseCritical(reason, messagePath(reason, meta, message, ctx.quotedPath()))
 }
 
 func raiseNoParse(ctx context, meta *Meta) Error {
 	reason := ErrNoParse
-	return raisePathErr(reason, meta, "", ctx.path("."))
+	return raisePathErr(reason, meta, "", ctx.structuredPath())
 }
 
 func raiseNil(reason error) Error {
@@ -292,7 +376,7 @@ func raiseToTypeNotSupported(opts *options, v value, goT reflect.Type) Error {
 		t.name, goT)
 	ctx := v.Context()
 
-	return raiseCritical(reason, messagePath(reason, v.meta(), message, ctx.path(".")))
+	return raiseCritical(reason, messagePath(reason, v.meta(), message, ctx.quotedPath()))
 }
 
 func raiseArraySize(ctx context, meta *Meta, n int, to int) Error {
@@ -300,12 +384,12 @@ func raiseArraySize(ctx context, meta *Meta, n int, to int) Error {
 	message := fmt.Sprintf("array of length %v does not meet required length %v",
 		n, to)
 
-	return raisePathErr(reason, meta, message, ctx.path("."))
+	return raisePathErr(reason, meta, message, ctx.structuredPath())
 }
 
 func raiseConversion(opts *options, v value, err error, to string) Error {
 	ctx := v.Context()
-	path := ctx.path(".")
+	path := ctx.structuredPath()
 	t, _ := v.typ(opts)
 	message := fmt.Sprintf("can not convert '%v' into '%v'", t.name, to)
 	return raisePathErr(err, v.meta(), message, path)
@@ -313,7 +397,7 @@ func raiseConversion(opts *options, v value, err error, to string) Error {
 
 func raiseExpectedObject(opts *options, v value) Error {
 	ctx := v.Context()
-	path := ctx.path(".")
+	path := ctx.quotedPath()
 	t, _ := v.typ(opts)
 	message := fmt.Sprintf("required 'object', but found '%v' in field '%v'",
 		t.name, path)
@@ -323,28 +407,171 @@ func raiseExpectedObject(opts *options, v value) Error {
 
 func raiseInvalidDuration(v value, err error) Error {
 	ctx := v.Context()
-	path := ctx.path(".")
+	path := ctx.structuredPath()
 	return raisePathErr(err, v.meta(), "", path)
 }
 
 func raiseValidation(ctx context, meta *Meta, field string, err error) Error {
-	path := ""
-	if field == "" {
-		path = ctx.path(".")
-	} else {
-		path = ctx.pathOf(field, ".")
+	path := ctx.structuredPath()
+	if field != "" {
+		path = ctx.structuredPathOf(field)
 	}
-	return raiseErr(err, messagePath(err, meta, err.Error(), path))
+	return baseError{err, ErrConfig, messagePath(err, meta, err.Error(), path.String(".")), path}
 }
 
 func raiseInvalidRegexp(v value, err error) Error {
 	ctx := v.Context()
-	path := ctx.path(".")
+	path := ctx.structuredPath()
 	message := fmt.Sprintf("Failed to compile regular expression with '%v'", err)
 	return raisePathErr(err, v.meta(), message, path)
 }
 
+func raiseInvalidURL(v value, err error) Error {
+	ctx := v.Context()
+	message := fmt.Sprintf("invalid URL: %v", err)
+	return raisePathErr(wrapReason(ErrInvalidURL, err), v.meta(), message, ctx.structuredPath())
+}
+
+func raiseInvalidIP(v value, err error) Error {
+	ctx := v.Context()
+	message := fmt.Sprintf("invalid IP address: %v", err)
+	return raisePathErr(wrapReason(ErrInvalidIP, err), v.meta(), message, ctx.structuredPath())
+}
+
+func raiseInvalidCIDR(v value, err error) Error {
+	ctx := v.Context()
+	message := fmt.Sprintf("invalid CIDR: %v", err)
+	return raisePathErr(wrapReason(ErrInvalidCIDR, err), v.meta(), message, ctx.structuredPath())
+}
+
+func raiseInvalidTime(v value, err error) Error {
+	ctx := v.Context()
+	message := fmt.Sprintf("invalid RFC3339 timestamp: %v", err)
+	return raisePathErr(wrapReason(ErrInvalidTime, err), v.meta(), message, ctx.structuredPath())
+}
+
+func raiseInvalidLocation(v value, err error) Error {
+	ctx := v.Context()
+	message := fmt.Sprintf("invalid time zone location: %v", err)
+	return raisePathErr(wrapReason(ErrInvalidLocation, err), v.meta(), message, ctx.structuredPath())
+}
+
+func raiseInvalidFileMode(v value, err error) Error {
+	ctx := v.Context()
+	message := fmt.Sprintf("invalid file mode: %v", err)
+	return raisePathErr(wrapReason(ErrInvalidFileMode, err), v.meta(), message, ctx.structuredPath())
+}
+
+func raiseInvalidByteSize(v value, err error) Error {
+	ctx := v.Context()
+	message := fmt.Sprintf("invalid byte size: %v", err)
+	return raisePathErr(wrapReason(ErrInvalidByteSize, err), v.meta(), message, ctx.structuredPath())
+}
+
+// reasonError wraps the cause of a failing conversion, such that errors.Is
+// matches the reason and the cause.
+type reasonError struct {
+	reason error
+	cause  error
+}
+
+func wrapReason(reason, cause error) error {
+	return reasonError{reason: reason, cause: cause}
+}
+
+func (e reasonError) Error() string        { return e.cause.Error() }
+func (e reasonError) Unwrap() error        { return e.cause }
+func (e reasonError) Is(target error) bool { return target == e.reason }
+
+func raiseConverter(v value, t reflect.Type, err error) Error {
+	ctx := v.Context()
+	message := fmt.Sprintf("can not convert value into '%v': %v", t, err)
+	return raisePathErr(err, v.meta(), message, ctx.structuredPath())
+}
+
+func raiseUnmarshal(v value, t reflect.Type, err error) Error {
+	ctx := v.Context()
+	path := ctx.structuredPath()
+	message := fmt.Sprintf("can not unmarshal value into '%v': %v", t, err)
+	return raisePathErr(err, v.meta(), message, path)
+}
+
 func raiseParseSplice(ctx context, meta *Meta, err error) Error {
 	message := fmt.Sprintf("%v parsing splice", err)
-	return raisePathErr(err, meta, message, ctx.path("."))
+	return raisePathErr(err, meta, message, ctx.structuredPath())
+}
+
+func raiseMissingDiscriminator(cfg *Config, field string) Error {
+	message := fmt.Sprintf("missing type discriminator field '%v'", field)
+	return raisePathErr(ErrMissing, cfg.metadata, message, cfg.ctx.structuredPathOf(field))
+}
+
+func raiseUnknownType(v value, t reflect.Type, name string, known []string) Error {
+	ctx := v.Context()
+	registered := "<none>"
+	if len(known) > 0 {
+		registered = strings.Join(known, ", ")
+	}
+	message := fmt.Sprintf("unknown type '%v' for '%v' (registered: %v)", name, t, registered)
+	return raisePathErr(ErrUnknownType, v.meta(), message, ctx.structuredPath())
+}
+
+func raiseNilInstance(v value, t reflect.Type, name string) Error {
+	ctx := v.Context()
+	path := ctx.structuredPath()
+	message := fmt.Sprintf("constructor of type '%v' for '%v' returned nil", name, t)
+	message = messagePath(ErrNilInstance, v.meta(), message, path.String("."))
+	return baseError{ErrNilInstance, ErrImplementation, message, path}
+}
+
+func raiseTypeKeyCount(cfg *Config, n int) Error {
+	message := fmt.Sprintf("exactly one key selecting the type required, but found %v", n)
+	return raisePathErr(ErrTypeKeyCount, cfg.metadata, message, cfg.ctx.structuredPath())
+}
+
+func raiseConflict(opts *options, old, v value) Error {
+	message := fmt.Sprintf("conflicting values %v%v and %v",
+		describeValue(opts, old), describeSource(old.meta()),
+		describeValue(opts, v))
+	ctx := old.Context()
+	return raisePathErr(ErrConflict, v.meta(), message, ctx.structuredPath())
+}
+
+func raiseAliasConflict(cfg *Config, field, name, alias string, meta *Meta) Error {
+	message := fmt.Sprintf("conflicting settings '%v' and '%v'", name, alias)
+	return raisePathErr(ErrConflict, meta, message, cfg.ctx.structuredPathOf(field))
+}
+
+func describeValue(opts *options, v value) string {
+	if sub, ok := v.(cfgSub); ok && sub.c.IsArray() {
+		return "array"
+	}
+	if !isSub(v) {
+		if r, err := v.reify(opts); err == nil {
+			return fmt.Sprintf("'%v'", r)
+		}
+	}
+
+	t, err := v.typ(opts)
+	if err != nil {
+		return "<unknown>"
+	}
+	return t.name
+}
+
+func describeSource(meta *Meta) string {
+	if meta == nil || meta.Source == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (source:'%v')", meta.Source)
+}
+
+func raiseInvalidPathPattern(pattern, reason string) Error {
+	message := fmt.Sprintf("invalid path pattern '%v': %v", pattern, reason)
+	return raiseErr(ErrInvalidPathPattern, message)
+}
+
+func raiseInvalidQuery(query, reason string) Error {
+	message := fmt.Sprintf("invalid query '%v': %v", query, reason)
+	return raiseErr(ErrInvalidQuery, message)
 }
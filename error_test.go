@@ -54,7 +54,7 @@ func TestErrorUnwrap(t *testing.T) {
 			target: ErrPointerRequired,
 		},
 		"pathError with ErrDuplicateKey": {
-			err:    raisePathErr(ErrDuplicateKey, nil, "test", "a.b"),
+			err:    raisePathErr(ErrDuplicateKey, nil, "test", ParsePath("a.b", ".")),
 			target: ErrDuplicateKey,
 		},
 	}
@@ -67,6 +67,46 @@ func TestErrorUnwrap(t *testing.T) {
 	}
 }
 
+func TestErrorStructuredPath(t *testing.T) {
+	c := MustNewFrom(map[string]interface{}{
+		"labels": map[string]interface{}{"app.kubernetes.io/name": "nginx"},
+	})
+
+	var settings struct {
+		Labels map[string]int
+	}
+	err := c.Unpack(&settings)
+	if assert.Error(t, err) {
+		ucfgErr := err.(Error)
+		assert.Equal(t, `labels["app.kubernetes.io/name"]`, ucfgErr.Path())
+		assert.Equal(t, []PathSegment{
+			{Kind: SegmentName, Name: "labels"},
+			{Kind: SegmentName, Name: "app.kubernetes.io/name"},
+		}, ErrorPath(err).Segments())
+		assert.Equal(t, ErrorPath(err), ErrorPath(fmt.Errorf("wrapped: %w", err)))
+	}
+
+	c = MustNewFrom(map[string]interface{}{
+		"hosts": []interface{}{
+			map[string]interface{}{"x": map[string]interface{}{"a.b": "b"}},
+		},
+	})
+	var hosts struct {
+		Hosts []struct{ X map[string]int }
+	}
+	err = c.Unpack(&hosts)
+	if assert.Error(t, err) {
+		assert.Equal(t, []PathSegment{
+			{Kind: SegmentName, Name: "hosts"},
+			{Kind: SegmentIndex, Index: 0},
+			{Kind: SegmentName, Name: "x"},
+			{Kind: SegmentName, Name: "a.b"},
+		}, ErrorPath(err).Segments())
+	}
+
+	assert.Equal(t, Path{}, ErrorPath(errors.New("other error")))
+}
+
 func TestErrorMessages(t *testing.T) {
 	goldenPath := path.Join("testdata", "error", "message")
 
@@ -45,8 +45,13 @@ import (
 //
 // If autoBool is enabled (default if Config or ConfigVar is used), keys without
 // value are converted to bool variable with value being true.
+//
+// Keys with an append element, like 'hosts.+' or 'hosts[]', add the value to
+// the end of the array, such that arrays can be extended without knowing
+// their length.
 func NewFlagKeyValue(cfg *ucfg.Config, autoBool bool, opts ...ucfg.Option) *FlagValue {
-	return newFlagValue(cfg, opts, func(arg string) (*ucfg.Config, error, error) {
+	var v *FlagValue
+	v = newFlagValue(cfg, opts, func(arg string) (*ucfg.Config, error, error) {
 		var key string
 		var val interface{}
 		var err error
@@ -72,8 +77,28 @@ func NewFlagKeyValue(cfg *ucfg.Config, autoBool bool, opts ...ucfg.Option) *Flag
 			}
 		}
 
-		tmp := map[string]interface{}{key: val}
-		cfg, err := ucfg.NewFrom(tmp, opts...)
+		// Merging a new array into the collected configuration would overwrite
+		// existing elements. Appended values are set directly instead.
+		if isAppendKey(key, opts) {
+			err := ucfg.Set(v.Config(), key, val, opts...)
+			return nil, err, err
+		}
+
+		// keys are set as paths, such that names can be quoted in brackets
+		cfg := ucfg.New()
+		err = ucfg.Set(cfg, key, val, opts...)
 		return cfg, err, err
 	})
+	return v
+}
+
+// isAppendKey checks if key contains an element appending to an array, like
+// 'hosts.+' or 'hosts[]'.
+func isAppendKey(key string, opts []ucfg.Option) bool {
+	for _, segment := range ucfg.ParsePathWithOptions(key, opts...).Segments() {
+		if segment.Kind == ucfg.SegmentAppend {
+			return true
+		}
+	}
+	return false
 }
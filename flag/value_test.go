@@ -117,6 +117,59 @@ func TestMergeFlagValueNewList(t *testing.T) {
 	assert.True(t, b)
 }
 
+func TestFlagValueAppend(t *testing.T) {
+	config, err := parseTestFlags("-D hosts.0=a -D hosts.+=b -D hosts[]=c -D inputs.+.type=log -D inputs.0.port=5044")
+	require.NoError(t, err)
+	require.NotNil(t, config)
+
+	var settings struct {
+		Hosts  []string
+		Inputs []struct {
+			Type string
+			Port int
+		}
+	}
+	require.NoError(t, config.Unpack(&settings))
+	assert.Equal(t, []string{"a", "b", "c"}, settings.Hosts)
+	require.Len(t, settings.Inputs, 1)
+	assert.Equal(t, "log", settings.Inputs[0].Type)
+	assert.Equal(t, 5044, settings.Inputs[0].Port)
+}
+
+func TestFlagValueBracketKey(t *testing.T) {
+	config, err := parseTestFlags(`-D labels["app.kubernetes.io/name"]=nginx -D list[1]=b`)
+	require.NoError(t, err)
+	require.NotNil(t, config)
+
+	labels, err := config.Child("labels", -1)
+	require.NoError(t, err)
+	name, err := labels.String("app.kubernetes.io/name", -1)
+	require.NoError(t, err)
+	assert.Equal(t, "nginx", name)
+
+	s, err := config.String("list", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "b", s)
+}
+
+func TestIsAppendKey(t *testing.T) {
+	cases := map[string]bool{
+		"hosts":        false,
+		"hosts.+":      true,
+		"hosts[]":      true,
+		"+":            true,
+		"inputs.+.id":  true,
+		"a+b":          false,
+		"hosts.1":      false,
+		"inputs.+1.id": false,
+		`labels["+"]`:  false,
+	}
+	opts := []ucfg.Option{ucfg.PathSep(".")}
+	for key, expected := range cases {
+		assert.Equal(t, expected, isAppendKey(key, opts), key)
+	}
+}
+
 func parseTestFlags(args string) (*ucfg.Config, error) {
 	fs := goflag.NewFlagSet("test", goflag.ContinueOnError)
 	config := Config(fs, "D", "overwrite", ucfg.PathSep("."))
@@ -17,6 +17,8 @@
 
 package ucfg
 
+import "reflect"
+
 // ******************************************************************************
 // Low level getters and setters
 // ******************************************************************************
@@ -168,6 +170,107 @@ func (c *Config) Child(name string, idx int, opts ...Option) (*Config, error) {
 	return c, convertErr(O, v, fail, "object")
 }
 
+// BoolAt reads a boolean setting at the path p like Bool.
+//
+// BoolAt supports the options: Env, Resolve, ResolveEnv
+func (c *Config) BoolAt(p Path, opts ...Option) (bool, error) {
+	O := makeOptions(opts)
+	v, err := c.getPath(p.cfgPath("."), O)
+	if err != nil {
+		return false, err
+	}
+	b, fail := v.toBool(O)
+	return b, convertErr(O, v, fail, "bool")
+}
+
+// StringAt reads a string setting at the path p like String.
+//
+// StringAt supports the options: Env, Resolve, ResolveEnv
+func (c *Config) StringAt(p Path, opts ...Option) (string, error) {
+	O := makeOptions(opts)
+	v, err := c.getPath(p.cfgPath("."), O)
+	if err != nil {
+		return "", err
+	}
+	s, fail := v.toString(O)
+	return s, convertErr(O, v, fail, "string")
+}
+
+// IntAt reads an int64 setting at the path p like Int.
+//
+// IntAt supports the options: Env, Resolve, ResolveEnv
+func (c *Config) IntAt(p Path, opts ...Option) (int64, error) {
+	O := makeOptions(opts)
+	v, err := c.getPath(p.cfgPath("."), O)
+	if err != nil {
+		return 0, err
+	}
+	i, fail := v.toInt(O)
+	return i, convertErr(O, v, fail, "int")
+}
+
+// UintAt reads an uint64 setting at the path p like Uint.
+//
+// UintAt supports the options: Env, Resolve, ResolveEnv
+func (c *Config) UintAt(p Path, opts ...Option) (uint64, error) {
+	O := makeOptions(opts)
+	v, err := c.getPath(p.cfgPath("."), O)
+	if err != nil {
+		return 0, err
+	}
+	u, fail := v.toUint(O)
+	return u, convertErr(O, v, fail, "uint")
+}
+
+// FloatAt reads a float64 setting at the path p like Float.
+//
+// FloatAt supports the options: Env, Resolve, ResolveEnv
+func (c *Config) FloatAt(p Path, opts ...Option) (float64, error) {
+	O := makeOptions(opts)
+	v, err := c.getPath(p.cfgPath("."), O)
+	if err != nil {
+		return 0, err
+	}
+	f, fail := v.toFloat(O)
+	return f, convertErr(O, v, fail, "float")
+}
+
+// ChildAt returns the child configuration at the path p like Child. The empty
+// path returns c.
+//
+// ChildAt supports the options: Env, Resolve, ResolveEnv
+func (c *Config) ChildAt(p Path, opts ...Option) (*Config, error) {
+	O := makeOptions(opts)
+	v, err := c.getPath(p.cfgPath("."), O)
+	if err != nil {
+		return nil, err
+	}
+	c, fail := v.toConfig(O)
+	return c, convertErr(O, v, fail, "object")
+}
+
+// MetaOf returns the meta data (e.g. the source file) stored with a setting.
+// MetaOf returns nil if no meta data is available for the setting.
+//
+// The setting path is constructed from name and idx. If name is set and idx is -1,
+// only the name is used to access the setting by name. If name is empty, idx
+// must be >= 0, assuming the Config is a list. If both name and idx are set,
+// the name must point to a list.
+//
+// MetaOf supports the options: PathSep
+func (c *Config) MetaOf(name string, idx int, opts ...Option) (*Meta, error) {
+	O := makeOptions(opts)
+	p := parsePathIdx(name, idx, O)
+	v, err := p.GetValue(c, O)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, raiseMissingPath(c, p)
+	}
+	return v.meta(), nil
+}
+
 // SetBool sets a boolean primitive value. An error is returned if the new name
 // is invalid.
 //
@@ -252,14 +355,22 @@ func (c *Config) SetChild(name string, idx int, value *Config, opts ...Option) e
 
 // getField supports the options: PathSep, Env, Resolve, ResolveEnv
 func (c *Config) getField(name string, idx int, opts *options) (value, Error) {
-	p := parsePathIdx(name, idx, opts)
+	return c.getPath(parsePathIdx(name, idx, opts), opts)
+}
+
+// getPath supports the options: Env, Resolve, ResolveEnv
+func (c *Config) getPath(p cfgPath, opts *options) (value, Error) {
+	if len(p.fields) == 0 {
+		return cfgSub{c}, nil
+	}
+
 	v, err := p.GetValue(c, opts)
 	if err != nil {
 		return v, err
 	}
 
 	if v == nil {
-		return nil, raiseMissing(c, p.String())
+		return nil, raiseMissingPath(c, p)
 	}
 	return v, nil
 }
@@ -279,3 +390,128 @@ func (c *Config) setField(name string, idx int, v value, options []Option) Error
 	}
 	return nil
 }
+
+// ******************************************************************************
+// Generic getters and setters
+// ******************************************************************************
+
+// Get reads the setting at path and unpacks it into a new value of type T.
+// Get applies the same conversion rules as Unpack, supporting primitives,
+// special types like time.Duration or *regexp.Regexp, structs, slices, maps,
+// Unpacker implementations and validators.
+//
+// Get returns an error with reason ErrMissing if the setting does not exist.
+//
+// Get supports the options: PathSep, StructTag, ValidatorTag, Env, Resolve,
+// ResolveEnv, NoValidate
+func Get[T any](cfg *Config, path string, opts ...Option) (T, error) {
+	var to T
+	if cfg == nil {
+		return to, raiseNil(ErrNilConfig)
+	}
+
+	O := makeOptions(opts)
+	v, err := cfg.getField(path, -1, O)
+	if err != nil {
+		return to, err
+	}
+	return getValue[T](O, v)
+}
+
+func getValue[T any](opts *options, v value) (T, error) {
+	var to T
+	vTo := reflect.ValueOf(&to).Elem()
+	if isNil(v) && vTo.Kind() == reflect.Ptr {
+		return to, nil
+	}
+
+	res, err := reifyMergeValue(fieldOptions{opts: opts}, vTo, v)
+	if err != nil {
+		return to, err
+	}
+	if res.IsValid() {
+		vTo.Set(pointerize(vTo.Type(), res.Type(), res))
+	}
+	return to, nil
+}
+
+// GetAt reads the setting at the path p like Get.
+//
+// GetAt supports the options: StructTag, ValidatorTag, Env, Resolve,
+// ResolveEnv, NoValidate
+func GetAt[T any](cfg *Config, p Path, opts ...Option) (T, error) {
+	var to T
+	if cfg == nil {
+		return to, raiseNil(ErrNilConfig)
+	}
+
+	O := makeOptions(opts)
+	v, err := cfg.getPath(p.cfgPath("."), O)
+	if err != nil {
+		return to, err
+	}
+	return getValue[T](O, v)
+}
+
+// GetOr reads the setting at path like Get, but returns fallback if the
+// setting does not exist. Conversion and validation errors are still
+// returned.
+func GetOr[T any](cfg *Config, path string, fallback T, opts ...Option) (T, error) {
+	v, err := Get[T](cfg, path, opts...)
+	if err != nil {
+		if isMissingError(err) {
+			return fallback, nil
+		}
+		return v, err
+	}
+	return v, nil
+}
+
+// MustGet reads the setting at path like Get, but panics if the setting
+// can not be read.
+func MustGet[T any](cfg *Config, path string, opts ...Option) T {
+	v, err := Get[T](cfg, path, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Set normalizes v the same way as Merge does and stores the result at
+// path, replacing any existing setting.
+//
+// Set supports the options: PathSep, MetaData, StructTag, VarExp, MarshalText
+func Set[T any](cfg *Config, path string, v T, opts ...Option) error {
+	if cfg == nil {
+		return raiseNil(ErrNilConfig)
+	}
+
+	O := makeOptions(opts)
+	return setNormalized(cfg, parsePathWithOpts(path, O), O, v)
+}
+
+// SetAt stores v at the path p like Set. The keys of maps in v are only split
+// if the PathSep option is set.
+//
+// SetAt supports the options: PathSep, MetaData, StructTag, VarExp, MarshalText
+func SetAt[T any](cfg *Config, p Path, v T, opts ...Option) error {
+	if cfg == nil {
+		return raiseNil(ErrNilConfig)
+	}
+
+	O := makeOptions(opts)
+	return setNormalized(cfg, p.cfgPath("."), O, v)
+}
+
+func setNormalized(cfg *Config, p cfgPath, opts *options, v interface{}) error {
+	var val value
+	if rv := reflect.ValueOf(v); !rv.IsValid() {
+		val = &cfgNil{cfgPrimitive{metadata: opts.meta}}
+	} else {
+		var err Error
+		if val, err = normalizeValue(opts, tagOptions{}, context{}, rv); err != nil {
+			return err
+		}
+	}
+	return p.SetValue(cfg, opts, val)
+}
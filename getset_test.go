@@ -18,9 +18,13 @@
 package ucfg
 
 import (
+	"errors"
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSetGetPrimitives(t *testing.T) {
@@ -180,6 +184,269 @@ func TestSetGetArray(t *testing.T) {
 	assert.Equal(t, "a.5", child.Path("."))
 }
 
+func TestSetGetNegativeIndex(t *testing.T) {
+	c := MustNewFrom(map[string]interface{}{
+		"hosts": []string{"a", "b", "c"},
+	})
+	opts := PathSep(".")
+
+	s, err := c.String("hosts.-1", -1, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "c", s)
+
+	s, err = c.String("hosts.-3", -1, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "a", s)
+
+	_, err = c.String("hosts.-4", -1, opts)
+	assert.Error(t, err)
+
+	require.NoError(t, c.SetString("hosts.-2", -1, "x", opts))
+	s, err = c.String("hosts", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "x", s)
+
+	err = c.SetString("hosts.-4", -1, "x", opts)
+	assert.True(t, errors.Is(err, ErrIndexOutOfRange))
+
+	removed, err := c.Remove("hosts.-1", -1, opts)
+	require.NoError(t, err)
+	assert.True(t, removed)
+	assert.Equal(t, []string{"a", "x"}, MustGet[[]string](c, "hosts"))
+}
+
+func TestSetGetSlice(t *testing.T) {
+	newConfig := func() *Config {
+		return MustNewFrom(map[string]interface{}{
+			"list": []interface{}{0, 1, 2, 3, 4},
+		})
+	}
+	opts := PathSep(".")
+
+	cases := map[string][]int{
+		"list.1:3":   {1, 2},
+		"list.:2":    {0, 1},
+		"list.3:":    {3, 4},
+		"list.-2:":   {3, 4},
+		"list.1:-1":  {1, 2, 3},
+		"list.3:1":   {},
+		"list.2:100": {2, 3, 4},
+	}
+	for path, expected := range cases {
+		var actual []int
+		sub, err := newConfig().Child(path, -1, opts)
+		require.NoError(t, err, path)
+		require.NoError(t, sub.Unpack(&actual), path)
+		if len(expected) == 0 {
+			assert.Empty(t, actual, path)
+		} else {
+			assert.Equal(t, expected, actual, path)
+		}
+	}
+
+	t.Run("slice keeps element paths", func(t *testing.T) {
+		sub, err := newConfig().Child("list.1:3", -1, opts)
+		require.NoError(t, err)
+		_, err = sub.Child("", 0)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "list.1")
+	})
+
+	t.Run("set slice", func(t *testing.T) {
+		c := newConfig()
+		child := MustNewFrom([]interface{}{"a", "b", "c"})
+		require.NoError(t, c.SetChild("list.1:3", -1, child, opts))
+		assert.Equal(t, []string{"0", "a", "b", "c", "3", "4"}, MustGet[[]string](c, "list"))
+
+		// element contexts are updated
+		s, err := c.Child("list", -1)
+		require.NoError(t, err)
+		_, err = s.Int("", 3)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "list.3")
+
+		require.NoError(t, c.SetInt("list.:2", -1, 42, opts))
+		assert.Equal(t, []string{"42", "b", "c", "3", "4"}, MustGet[[]string](c, "list"))
+	})
+
+	t.Run("set through slice fails", func(t *testing.T) {
+		c := MustNewFrom(map[string]interface{}{
+			"list": []interface{}{map[string]interface{}{"a": 1}},
+		})
+		err := c.SetInt("list.0:1.0.a", -1, 2, opts)
+		assert.True(t, errors.Is(err, ErrExpectedObject))
+
+		_, err = c.Remove("list.0:1.0", -1, opts)
+		assert.True(t, errors.Is(err, ErrExpectedObject))
+	})
+
+	t.Run("remove slice", func(t *testing.T) {
+		c := newConfig()
+		removed, err := c.Remove("list.1:3", -1, opts)
+		require.NoError(t, err)
+		assert.True(t, removed)
+		assert.Equal(t, []int{0, 3, 4}, MustGet[[]int](c, "list"))
+
+		removed, err = c.Remove("list.2:2", -1, opts)
+		require.NoError(t, err)
+		assert.False(t, removed)
+	})
+}
+
+func TestSetAppend(t *testing.T) {
+	c := New()
+	opts := PathSep(".")
+
+	require.NoError(t, c.SetString("hosts.+", -1, "a", opts))
+	require.NoError(t, c.SetString("hosts[]", -1, "b", opts))
+	require.NoError(t, c.SetString("inputs.+.type", -1, "log", opts))
+	require.NoError(t, c.SetString("inputs.+.type", -1, "tcp", opts))
+
+	assert.Equal(t, []string{"a", "b"}, MustGet[[]string](c, "hosts"))
+
+	var inputs []struct{ Type string }
+	sub, err := c.Child("inputs", -1)
+	require.NoError(t, err)
+	require.NoError(t, sub.Unpack(&inputs))
+	require.Len(t, inputs, 2)
+	assert.Equal(t, "log", inputs[0].Type)
+	assert.Equal(t, "tcp", inputs[1].Type)
+
+	n, err := c.CountField("hosts.+", opts)
+	assert.Error(t, err)
+	assert.Equal(t, -1, n)
+
+	removed, err := c.Remove("hosts.+", -1, opts)
+	require.NoError(t, err)
+	assert.False(t, removed)
+}
+
+func TestSetGetBracketPath(t *testing.T) {
+	c := New()
+	opts := PathSep(".")
+
+	require.NoError(t, c.SetString(`labels["app.kubernetes.io/name"]`, -1, "nginx", opts))
+	require.NoError(t, c.SetInt("list[1]", -1, 42, opts))
+
+	labels, err := c.Child("labels", -1, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app.kubernetes.io/name"}, labels.GetFields())
+
+	s, err := c.String(`labels['app.kubernetes.io/name']`, -1, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "nginx", s)
+
+	i, err := c.Int("list.1", -1, opts)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), i)
+
+	_, err = c.String(`labels["app.kubernetes.io/version"]`, -1, opts)
+	require.Error(t, err)
+	assert.Equal(t, `missing field accessing 'labels["app.kubernetes.io/version"]'`, err.Error())
+
+	removed, err := c.Remove(`labels["app.kubernetes.io/name"]`, -1, opts)
+	require.NoError(t, err)
+	assert.True(t, removed)
+}
+
+func TestBracketPathReference(t *testing.T) {
+	c := MustNewFrom(map[string]interface{}{
+		"name": `${labels["app.kubernetes.io/name"]}`,
+	}, VarExp, PathSep("."))
+	require.NoError(t, c.SetString(`labels["app.kubernetes.io/name"]`, -1, "nginx", PathSep(".")))
+
+	s, err := c.String("name", -1, PathSep("."))
+	require.NoError(t, err)
+	assert.Equal(t, "nginx", s)
+}
+
+func TestGetAt(t *testing.T) {
+	c := MustNewFrom(map[string]interface{}{
+		"labels": map[string]interface{}{"app.kubernetes.io/name": "nginx"},
+		"inputs": []interface{}{
+			map[string]interface{}{"enabled": true, "port": 5044, "ratio": 0.5, "offset": -1},
+		},
+	})
+
+	input := Path{}.Append("inputs").Index(-1)
+
+	b, err := c.BoolAt(input.Append("enabled"))
+	require.NoError(t, err)
+	assert.True(t, b)
+
+	u, err := c.UintAt(input.Append("port"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5044), u)
+
+	i, err := c.IntAt(input.Append("offset"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(-1), i)
+
+	f, err := c.FloatAt(input.Append("ratio"))
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, f)
+
+	name := Path{}.Append("labels").Append("app.kubernetes.io/name")
+	s, err := c.StringAt(name)
+	require.NoError(t, err)
+	assert.Equal(t, "nginx", s)
+
+	sub, err := c.ChildAt(input)
+	require.NoError(t, err)
+	assert.Equal(t, "inputs.0", sub.Path("."))
+
+	root, err := c.ChildAt(Path{})
+	require.NoError(t, err)
+	assert.True(t, root == c)
+
+	port, err := GetAt[int](c, input.Append("port"))
+	require.NoError(t, err)
+	assert.Equal(t, 5044, port)
+
+	has, err := c.HasAt(name)
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	has, err = c.HasAt(input.Append("missing"))
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	removed, err := c.RemoveAt(name)
+	require.NoError(t, err)
+	assert.True(t, removed)
+
+	removed, err = c.RemoveAt(Path{})
+	require.NoError(t, err)
+	assert.False(t, removed)
+
+	_, err = c.StringAt(name)
+	require.Error(t, err)
+	assert.Equal(t, name, ErrorPath(err))
+	assert.Equal(t, `labels["app.kubernetes.io/name"]`, err.(Error).Path())
+}
+
+func TestSetAt(t *testing.T) {
+	c := New()
+	name := Path{}.Append("labels").Append("app.kubernetes.io/name")
+	require.NoError(t, SetAt(c, name, map[string]interface{}{"a.b": 1}))
+
+	v, err := GetAt[int](c, name.Append("a.b"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+
+	require.NoError(t, SetAt(c, name, map[string]interface{}{"a.b": 2}, PathSep(".")))
+	v, err = Get[int](c, `labels["app.kubernetes.io/name"].a.b`, PathSep("."))
+	require.NoError(t, err)
+	assert.Equal(t, 2, v)
+
+	hosts := Path{}.Append("hosts")
+	require.NoError(t, SetAt(c, hosts, []interface{}{}))
+	require.NoError(t, SetAt(c, hosts.Index(0), "localhost"))
+	host, err := c.StringAt(hosts.Index(-1))
+	require.NoError(t, err)
+	assert.Equal(t, "localhost", host)
+}
+
 func TestSetGetNestedPath(t *testing.T) {
 	c := New()
 	c.SetInt("a.1.b.0", -1, 23, PathSep("."))
@@ -217,3 +484,146 @@ func TestSetGetNestedPath(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 42, int(i))
 }
+
+type testHostPort struct {
+	Host string `config:"host" validate:"required"`
+	Port int    `config:"port"`
+}
+
+func (h *testHostPort) InitDefaults() {
+	h.Port = 9200
+}
+
+type testPositive int
+
+func (p testPositive) Validate() error {
+	if p < 0 {
+		return errors.New("negative")
+	}
+	return nil
+}
+
+func TestGenericGet(t *testing.T) {
+	c, err := NewFrom(map[string]interface{}{
+		"timeout": "5s",
+		"pattern": "^abc$",
+		"count":   "42",
+		"hosts":   []string{"a", "b"},
+		"output": map[string]interface{}{
+			"host": "localhost",
+		},
+		"neg": -1,
+	}, PathSep("."))
+	require.NoError(t, err)
+
+	timeout, err := Get[time.Duration](c, "timeout")
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, timeout)
+
+	re, err := Get[*regexp.Regexp](c, "pattern")
+	require.NoError(t, err)
+	assert.True(t, re.MatchString("abc"))
+
+	count, err := Get[uint8](c, "count")
+	require.NoError(t, err)
+	assert.Equal(t, uint8(42), count)
+
+	hosts, err := Get[[]string](c, "hosts")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, hosts)
+
+	host, err := Get[string](c, "output.host", PathSep("."))
+	require.NoError(t, err)
+	assert.Equal(t, "localhost", host)
+
+	output, err := Get[testHostPort](c, "output")
+	require.NoError(t, err)
+	assert.Equal(t, testHostPort{Host: "localhost", Port: 9200}, output)
+
+	generic, err := Get[interface{}](c, "hosts")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", "b"}, generic)
+
+	_, err = Get[testPositive](c, "neg")
+	assert.Error(t, err)
+
+	_, err = Get[int](c, "missing")
+	require.Error(t, err)
+	assert.Equal(t, ErrMissing, err.(Error).Reason())
+
+	_, err = Get[int](c, "hosts")
+	assert.Error(t, err)
+}
+
+func TestGenericGetOr(t *testing.T) {
+	c, err := NewFrom(map[string]interface{}{"port": 5601, "name": "abc"})
+	require.NoError(t, err)
+
+	port, err := GetOr(c, "port", 9200)
+	require.NoError(t, err)
+	assert.Equal(t, 5601, port)
+
+	port, err = GetOr(c, "other", 9200)
+	require.NoError(t, err)
+	assert.Equal(t, 9200, port)
+
+	_, err = GetOr(c, "name", 9200)
+	assert.Error(t, err)
+
+	assert.Equal(t, "abc", MustGet[string](c, "name"))
+	assert.Panics(t, func() { MustGet[string](c, "missing") })
+}
+
+func TestGenericSet(t *testing.T) {
+	c := New()
+	opts := []Option{PathSep("."), MetaData(Meta{Source: "test"})}
+
+	require.NoError(t, Set(c, "a.timeout", 10*time.Second, opts...))
+	require.NoError(t, Set(c, "a.hosts", []string{"x", "y"}, opts...))
+	require.NoError(t, Set(c, "a.output", testHostPort{Host: "h", Port: 1}, opts...))
+	require.NoError(t, Set[interface{}](c, "a.empty", nil, opts...))
+	require.NoError(t, Set(c, "b", map[string]int{"one": 1}, opts...))
+
+	timeout, err := c.String("a.timeout", -1, opts...)
+	require.NoError(t, err)
+	assert.Equal(t, "10s", timeout)
+
+	hosts, err := Get[[]string](c, "a.hosts", opts...)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"x", "y"}, hosts)
+
+	output, err := Get[testHostPort](c, "a.output", opts...)
+	require.NoError(t, err)
+	assert.Equal(t, testHostPort{Host: "h", Port: 1}, output)
+
+	empty, err := Get[*testHostPort](c, "a.empty", opts...)
+	require.NoError(t, err)
+	assert.Nil(t, empty)
+
+	one, err := c.Int("b.one", -1, opts...)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), one)
+
+	path, err := c.Child("a", -1)
+	require.NoError(t, err)
+	assert.Equal(t, "a", path.Path("."))
+}
+
+func TestMetaOf(t *testing.T) {
+	c, err := NewFrom(map[string]interface{}{
+		"a": map[string]interface{}{"b": 1},
+	}, PathSep("."), MetaData(Meta{Source: "base.yml"}))
+	require.NoError(t, err)
+	require.NoError(t, c.SetString("a.c", -1, "x", PathSep("."), MetaData(Meta{Source: "override.yml"})))
+
+	meta, err := c.MetaOf("a.b", -1, PathSep("."))
+	require.NoError(t, err)
+	assert.Equal(t, &Meta{Source: "base.yml"}, meta)
+
+	meta, err = c.MetaOf("a.c", -1, PathSep("."))
+	require.NoError(t, err)
+	assert.Equal(t, &Meta{Source: "override.yml"}, meta)
+
+	_, err = c.MetaOf("a.d", -1, PathSep("."))
+	assert.Error(t, err)
+}
@@ -18,16 +18,20 @@
 package json
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
 
 	"github.com/elastic/go-ucfg"
 )
 
 // NewConfig creates a new configuration object from the JSON string passed via in.
+// The order of keys in the JSON document is preserved.
 func NewConfig(in []byte, opts ...ucfg.Option) (*ucfg.Config, error) {
-	var m interface{}
-	if err := json.Unmarshal(in, &m); err != nil {
+	m, err := decodeOrdered(in)
+	if err != nil {
 		return nil, err
 	}
 	return ucfg.NewFrom(m, opts...)
@@ -45,3 +49,76 @@ func NewConfigWithFile(name string, opts ...ucfg.Option) (*ucfg.Config, error) {
 	}, opts...)
 	return NewConfig(input, opts...)
 }
+
+// decodeOrdered decodes a JSON document like json.Unmarshal into an
+// interface{}, but decodes objects into ucfg.MapSlice values keeping the
+// order of keys.
+func decodeOrdered(in []byte) (interface{}, error) {
+	// validate the document first, so to report the same errors as
+	// json.Unmarshal
+	if !json.Valid(in) {
+		var v interface{}
+		return nil, json.Unmarshal(in, &v)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(in))
+	return decodeValue(dec)
+}
+
+func decodeValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		m := ucfg.MapSlice{}
+		index := map[string]int{}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			// like json.Unmarshal, the last value of duplicate keys wins
+			key := tok.(string)
+			if i, exists := index[key]; exists {
+				m[i].Value = v
+				continue
+			}
+			index[key] = len(m)
+			m = append(m, ucfg.MapItem{Key: key, Value: v})
+		}
+		return m, closeDelim(dec)
+
+	case json.Delim('['):
+		arr := []interface{}{}
+		for dec.More() {
+			v, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, closeDelim(dec)
+
+	default:
+		return tok, nil
+	}
+}
+
+func closeDelim(dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	if _, ok := tok.(json.Delim); !ok && err == nil {
+		return fmt.Errorf("unexpected token %v", tok)
+	}
+	return err
+}
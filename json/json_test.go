@@ -119,3 +119,33 @@ func mustUnpack(t *testing.T, c *ucfg.Config, v interface{}) {
 	err := c.Unpack(v)
 	require.NoError(t, err, "failed to unpack config")
 }
+
+func TestKeyOrder(t *testing.T) {
+	input := `{
+		"zeta": {"z": 1, "y": [{"b": 1, "a": 2}]},
+		"alpha": 1,
+		"mid": null,
+		"zeta": {"x": 1, "w": 2}
+	}`
+
+	c, err := NewConfig([]byte(input))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"zeta", "alpha", "mid"}, c.GetFields())
+
+	zeta, err := c.Child("zeta", -1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"x", "w"}, zeta.GetFields())
+
+	c, err = NewConfig([]byte(`[{"b": 1, "a": 2}]`))
+	require.NoError(t, err)
+	elem, err := c.Child("", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "a"}, elem.GetFields())
+}
+
+func TestInvalidInput(t *testing.T) {
+	for _, input := range []string{`{"a": 1`, `{"a": }`, `[1, 2`, `{} {}`} {
+		_, err := NewConfig([]byte(input))
+		assert.Error(t, err, input)
+	}
+}
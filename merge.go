@@ -18,9 +18,14 @@
 package ucfg
 
 import (
+	"encoding"
 	"fmt"
+	"net"
+	"net/url"
+	"os"
 	"reflect"
 	"regexp"
+	"sort"
 	"time"
 	"unicode"
 	"unicode/utf8"
@@ -31,7 +36,9 @@ import (
 // Merge traverses the value from recursively copying all values into a hierarchy
 // of Config objects plus primitives into c.
 //
-// Merge supports the options: PathSep, MetaData, StructTag, VarExp, ReplaceValues, AppendValues, PrependValues
+// Merge supports the options: PathSep, MetaData, StructTag, VarExp, ReplaceValues, AppendValues, PrependValues, MergePatch,
+// DetectConflicts, AllowOverrides, AllowOverridePatterns, PathMergeValues, PathReplaceValues, PathAppendValues,
+// PathPrependValues, PathMergePatch, MarshalText
 //
 // Merge uses the type-dependent default encodings:
 //   - Boolean values are encoded as booleans.
@@ -43,8 +50,12 @@ import (
 //     other setting by absolute name.
 //   - Array and slices are copied into new Config objects with index accessors only.
 //   - Struct values and maps with key type string are encoded as Config objects with
-//     named field accessors.
+//     named field accessors. The keys of Go maps are added in sorted order, while
+//     the order of keys in a MapSlice is preserved.
 //   - Config objects will be copied and added to the current hierarchy.
+//   - The special types supported by Unpack, like time.Duration, net.IP or
+//     time.Time, are encoded as strings. If the MarshalText option is set, all
+//     types implementing encoding.TextMarshaler are encoded as strings.
 //
 // The `config` struct tag (configurable via StructTag option) can be used to
 // set the field name and enable additional merging settings per field:
@@ -97,17 +108,20 @@ func mergeConfigDict(opts *options, to, from *Config) Error {
 	}
 
 	ok := false
+	var replaced map[string]value
 	if opts.configValueHandling == cfgReplaceValue {
-		old := to.fields.dict()
-		to.fields.d = nil
+		old, oldKeys := to.fields.dict(), to.fields.dictKeys()
+		replaced = old
+		to.fields.d, to.fields.keys, to.fields.stale = nil, nil, 0
 		defer func() {
 			if !ok {
-				to.fields.d = old
+				to.fields.d, to.fields.keys, to.fields.stale = old, oldKeys, 0
 			}
 		}()
 	}
 
-	for k, v := range dict {
+	for _, k := range from.fields.dictKeys() {
+		v := dict[k]
 		ctx := context{
 			parent: cfgSub{to},
 			field:  k,
@@ -118,6 +132,21 @@ func mergeConfigDict(opts *options, to, from *Config) Error {
 		if err != nil {
 			return err
 		}
+		if len(opts.pathHandling) > 0 {
+			opts = pathOptsOverride(opts, to.ctx.structuredPathOf(k))
+		}
+
+		if _, isNil := v.(*cfgNil); isNil && opts.configValueHandling == cfgMergePatch {
+			to.fields.del(k)
+			continue
+		}
+
+		if replaced != nil {
+			if err := checkConflict(opts, replaced[k], v); err != nil {
+				return err
+			}
+		}
+
 		merged, err := mergeValues(opts, old, v)
 		if err != nil {
 			return err
@@ -132,12 +161,21 @@ func mergeConfigDict(opts *options, to, from *Config) Error {
 
 func mergeConfigArr(opts *options, to, from *Config) Error {
 	currHandling := opts.configValueHandling
+	mergeKey := opts.mergeKey
 	opts, err := fieldOptsOverride(opts, "*", -1)
 	if err != nil {
 		return err
 	}
 	switch currHandling {
 	case cfgReplaceValue, cfgArrReplaceValue:
+		if len(to.fields.array()) > 0 && len(from.fields.array()) > 0 {
+			if err := checkConflict(opts, cfgSub{to}, cfgSub{from}); err != nil {
+				return err
+			}
+		}
+		return mergeConfigReplaceArr(opts, to, from)
+
+	case cfgMergePatch:
 		return mergeConfigReplaceArr(opts, to, from)
 
 	case cfgArrPrepend:
@@ -146,6 +184,9 @@ func mergeConfigArr(opts *options, to, from *Config) Error {
 	case cfgArrAppend:
 		return mergeConfigAppendArr(opts, to, from)
 
+	case cfgMergeByKey:
+		return mergeConfigMergeByKeyArr(opts, mergeKey, to, from)
+
 	case cfgDefaultHandling, cfgMergeValues:
 		return mergeConfigMergeArr(opts, to, from)
 	default:
@@ -161,8 +202,9 @@ func mergeConfigReplaceArr(opts *options, to, from *Config) Error {
 
 	var parent value = cfgSub{to}
 	var fields = fields{
-		d: to.fields.d,
-		a: make([]value, 0, len(a)),
+		d:    to.fields.d,
+		keys: to.fields.dictKeys(),
+		a:    make([]value, 0, len(a)),
 	}
 	fields.append(parent, a)
 	*to.fields = fields
@@ -190,6 +232,9 @@ func mergeConfigMergeArr(opts *options, to, from *Config) Error {
 		if err != nil {
 			return err
 		}
+		if len(idxOpts.pathHandling) > 0 {
+			idxOpts = pathOptsOverride(idxOpts, to.ctx.structuredPath().Index(i))
+		}
 		old := to.fields.array()[i]
 		merged, err := mergeValues(idxOpts, old, arr[i])
 		if err != nil {
@@ -205,6 +250,76 @@ func mergeConfigMergeArr(opts *options, to, from *Config) Error {
 	return nil
 }
 
+// mergeConfigMergeByKeyArr merges the elements of from into the elements of
+// to with the same value in the key field. Elements without matching key are
+// appended.
+func mergeConfigMergeByKeyArr(opts *options, key string, to, from *Config) Error {
+	arr := from.fields.array()
+	if len(arr) == 0 {
+		return nil
+	}
+
+	var parent value = cfgSub{to}
+
+	// the key only applies to the array itself, not to nested arrays
+	elemOpts := *opts
+	elemOpts.configValueHandling = cfgDefaultHandling
+	elemOpts.mergeKey = ""
+
+	index := map[string]int{}
+	for i, v := range to.fields.array() {
+		if k, ok := arrElemKey(opts, key, v); ok {
+			if _, exists := index[k]; !exists {
+				index[k] = i
+			}
+		}
+	}
+
+	for _, v := range arr {
+		k, ok := arrElemKey(opts, key, v)
+		i, exists := index[k]
+		if !ok || !exists {
+			i = len(to.fields.array())
+			if ok {
+				index[k] = i
+			}
+			to.fields.append(parent, []value{v})
+			continue
+		}
+
+		ctx := context{
+			parent: parent,
+			field:  fmt.Sprintf("%v", i),
+		}
+		idxOpts := &elemOpts
+		if len(idxOpts.pathHandling) > 0 {
+			idxOpts = pathOptsOverride(idxOpts, to.ctx.structuredPath().Index(i))
+		}
+		merged, err := mergeValues(idxOpts, to.fields.array()[i], v)
+		if err != nil {
+			return err
+		}
+		to.fields.setAt(i, parent, merged.cpy(ctx))
+	}
+	return nil
+}
+
+// arrElemKey returns the value of the key field of an array element. Returns
+// false if the element is no object or has no key.
+func arrElemKey(opts *options, key string, v value) (string, bool) {
+	sub, err := v.toConfig(opts)
+	if err != nil || !sub.IsDict() {
+		return "", false
+	}
+
+	k, exists := sub.fields.get(key)
+	if !exists {
+		return "", false
+	}
+	s, err := k.toString(opts)
+	return s, err == nil
+}
+
 func mergeConfigPrependArr(opts *options, to, from *Config) Error {
 	a1 := to.fields.array()
 	a2 := from.fields.array()
@@ -214,8 +329,9 @@ func mergeConfigPrependArr(opts *options, to, from *Config) Error {
 
 	var parent value = cfgSub{to}
 	var fields = fields{
-		d: to.fields.d,
-		a: make([]value, 0, len(a1)+len(a2)),
+		d:    to.fields.d,
+		keys: to.fields.dictKeys(),
+		a:    make([]value, 0, len(a1)+len(a2)),
 	}
 	fields.append(parent, a2)
 	fields.append(parent, a1)
@@ -229,6 +345,10 @@ func mergeConfigAppendArr(opts *options, to, from *Config) Error {
 }
 
 func mergeValues(opts *options, old, v value) (value, Error) {
+	if opts.configValueHandling == cfgMergePatch {
+		return mergePatchValues(opts, old, v)
+	}
+
 	if old == nil {
 		return v, nil
 	}
@@ -237,11 +357,11 @@ func mergeValues(opts *options, old, v value) (value, Error) {
 	// sub-configuration, use new value only.
 	subOld, err := old.toConfig(opts)
 	if err != nil {
-		return v, nil
+		return v, checkConflict(opts, old, v)
 	}
 	subV, err := v.toConfig(opts)
 	if err != nil {
-		return v, nil
+		return v, checkConflict(opts, old, v)
 	}
 
 	// merge new and old evaluated sub-configurations and return subOld for
@@ -252,6 +372,71 @@ func mergeValues(opts *options, old, v value) (value, Error) {
 	return cfgSub{subOld}, nil
 }
 
+// checkConflict returns ErrConflict if DetectConflicts is set and v would
+// overwrite old with another value. Unset old values can always be overwritten.
+func checkConflict(opts *options, old, v value) Error {
+	if !opts.detectConflicts || isNil(old) || valuesEqual(opts, old, v) {
+		return nil
+	}
+	ctx := old.Context()
+	if matchAnyPathPattern(opts.allowOverridePatterns, ctx.structuredPath()) {
+		return nil
+	}
+	return raiseConflict(opts, old, v)
+}
+
+// valuesEqual checks if a and b evaluate to the same primitive value. Signed
+// and unsigned integers with the same value are considered equal.
+func valuesEqual(opts *options, a, b value) bool {
+	ra, err := a.reify(opts)
+	if err != nil {
+		return false
+	}
+	rb, err := b.reify(opts)
+	if err != nil {
+		return false
+	}
+
+	switch ra.(type) {
+	case int64, uint64:
+		switch rb.(type) {
+		case int64, uint64:
+			return fmt.Sprint(ra) == fmt.Sprint(rb)
+		}
+	}
+	return reflect.DeepEqual(ra, rb)
+}
+
+// mergePatchValues merges v into old following the JSON Merge Patch semantics.
+// If v is no dictionary, v replaces old. Otherwise v is merged into old, or
+// into an empty dictionary if old is no dictionary, removing null values.
+func mergePatchValues(opts *options, old, v value) (value, Error) {
+	if _, isNil := v.(*cfgNil); isNil {
+		return v, nil
+	}
+
+	patch, err := v.toConfig(opts)
+	if err != nil || patch.IsArray() {
+		return v, nil
+	}
+
+	var target *Config
+	if old != nil {
+		if sub, err := old.toConfig(opts); err == nil && !sub.IsArray() {
+			target = sub
+		}
+	}
+	if target == nil {
+		target = New()
+		target.metadata = patch.metadata
+	}
+
+	if err := mergeConfig(opts, target, patch); err != nil {
+		return nil, err
+	}
+	return cfgSub{target}, nil
+}
+
 // convert from into normalized *Config checking for errors
 // before merging generated(normalized) config with current config
 func normalize(opts *options, from interface{}) (*Config, Error) {
@@ -262,6 +447,8 @@ func normalize(opts *options, from interface{}) (*Config, Error) {
 		return vFrom.Addr().Interface().(*Config), nil
 	case tConfigMap:
 		return normalizeMap(opts, vFrom)
+	case tMapSlice:
+		return normalizeMapSlice(opts, vFrom.Interface().(MapSlice))
 	default:
 		// try to convert vFrom into Config (rebranding)
 		if v, ok := tryTConfig(vFrom); ok {
@@ -303,13 +490,22 @@ func normalizeMapInto(cfg *Config, opts *options, from reflect.Value) Error {
 		return raiseKeyInvalidTypeMerge(cfg, from.Type())
 	}
 
-	for _, k := range from.MapKeys() {
+	// add keys in sorted order, such that the key order does not depend on the
+	// random map iteration order
+	keys := from.MapKeys()
+	names := make([]string, len(keys))
+	for i, k := range keys {
 		k = chaseValueInterfaces(k)
 		if k.Kind() != reflect.String {
 			return raiseKeyInvalidTypeMerge(cfg, from.Type())
 		}
+		names[i] = k.String()
+	}
+	sort.Sort(mapKeysByName{names, keys})
 
-		err := normalizeSetField(cfg, opts, noTagOpts, k.String(), from.MapIndex(k))
+	cfg.fields.reserve(len(names))
+	for i, name := range names {
+		err := normalizeSetField(cfg, opts, noTagOpts, name, from.MapIndex(keys[i]))
 		if err != nil {
 			return err
 		}
@@ -317,6 +513,41 @@ func normalizeMapInto(cfg *Config, opts *options, from reflect.Value) Error {
 	return nil
 }
 
+type mapKeysByName struct {
+	names []string
+	keys  []reflect.Value
+}
+
+func (m mapKeysByName) Len() int           { return len(m.names) }
+func (m mapKeysByName) Less(i, j int) bool { return m.names[i] < m.names[j] }
+func (m mapKeysByName) Swap(i, j int) {
+	m.names[i], m.names[j] = m.names[j], m.names[i]
+	m.keys[i], m.keys[j] = m.keys[j], m.keys[i]
+}
+
+func normalizeMapSlice(opts *options, from MapSlice) (*Config, Error) {
+	cfg := New()
+	cfg.metadata = opts.meta
+	cfg.fields.reserve(len(from))
+	for _, item := range from {
+		err := normalizeSetField(cfg, opts, noTagOpts, item.Key, reflect.ValueOf(&item.Value).Elem())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+func normalizeMapSliceValue(opts *options, ctx context, from MapSlice) (value, Error) {
+	sub, err := normalizeMapSlice(opts, from)
+	if err != nil {
+		return nil, err
+	}
+	v := cfgSub{sub}
+	v.SetContext(ctx)
+	return v, nil
+}
+
 func normalizeStruct(opts *options, from reflect.Value) (*Config, Error) {
 	cfg := New()
 	cfg.metadata = opts.meta
@@ -378,7 +609,7 @@ func normalizeSetField(
 		return err
 	}
 
-	p := parsePathWithOpts(name, opts)
+	p := parseKeyPath(name, opts)
 	old, err := p.GetValue(cfg, opts)
 	if err != nil {
 		if err.Reason() != ErrMissing {
@@ -461,12 +692,39 @@ func normalizeValue(
 	v = chaseValue(v)
 
 	switch v.Type() {
+	case tMapSlice:
+		return normalizeMapSliceValue(opts, ctx, v.Interface().(MapSlice))
 	case tDuration:
 		d := v.Interface().(time.Duration)
 		return newString(ctx, opts.meta, d.String()), nil
 	case tRegexp:
 		r := v.Addr().Interface().(*regexp.Regexp)
 		return newString(ctx, opts.meta, r.String()), nil
+	case tURL:
+		u := v.Interface().(url.URL)
+		return newString(ctx, opts.meta, u.String()), nil
+	case tIPNet:
+		n := v.Interface().(net.IPNet)
+		return newString(ctx, opts.meta, n.String()), nil
+	case tLocation:
+		l := pointerize(reflect.PtrTo(tLocation), tLocation, v).Interface().(*time.Location)
+		return newString(ctx, opts.meta, l.String()), nil
+	case tFileMode:
+		m := v.Interface().(os.FileMode)
+		return newString(ctx, opts.meta, fmt.Sprintf("%#o", uint32(m))), nil
+	case tByteSize:
+		b := v.Interface().(ByteSize)
+		return newString(ctx, opts.meta, b.String()), nil
+	}
+
+	if opts.marshalText || reifyExtras[v.Type()] != nil {
+		if m, ok := asTextMarshaler(v); ok {
+			text, err := m.MarshalText()
+			if err != nil {
+				return nil, raisePathErr(err, opts.meta, "", ctx.structuredPath())
+			}
+			return newString(ctx, opts.meta, string(text)), nil
+		}
 	}
 
 	// handle primitives
@@ -509,6 +767,27 @@ func normalizeValue(
 	}
 }
 
+// asTextMarshaler returns v as encoding.TextMarshaler if v or a pointer to v
+// implements the interface. Nil pointers and interfaces are ignored.
+func asTextMarshaler(v reflect.Value) (encoding.TextMarshaler, bool) {
+	if !v.IsValid() || !v.CanInterface() {
+		return nil, false
+	}
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return nil, false
+	}
+
+	t := v.Type()
+	switch {
+	case t.Implements(tTextMarshaler):
+		return v.Interface().(encoding.TextMarshaler), true
+	case reflect.PtrTo(t).Implements(tTextMarshaler):
+		ptr := pointerize(reflect.PtrTo(t), t, v)
+		return ptr.Interface().(encoding.TextMarshaler), true
+	}
+	return nil, false
+}
+
 func normalizeString(ctx context, opts *options, str string) (value, Error) {
 	if !opts.varexp {
 		return newString(ctx, opts.meta, str), nil
@@ -534,6 +813,10 @@ func fieldOptsOverride(opts *options, fieldName string, idx int) (*options, Erro
 		return opts, nil
 	}
 	cfgHandling, child, ok := opts.fieldHandlingTree.fieldHandling(fieldName, idx)
+	mergeKey := opts.mergeKey
+	if ok && cfgHandling == cfgMergeByKey {
+		mergeKey = child.mergeKey()
+	}
 	child, err := includeWildcard(child, opts.fieldHandlingTree)
 	if err != nil {
 		return nil, err
@@ -550,15 +833,35 @@ func fieldOptsOverride(opts *options, fieldName string, idx int) (*options, Erro
 		return opts, nil
 	}
 	// Only return a new `options` if absolutely required.
-	if opts.configValueHandling != cfgHandling || opts.fieldHandlingTree != child {
+	if opts.configValueHandling != cfgHandling || opts.fieldHandlingTree != child || opts.mergeKey != mergeKey {
 		newOpts := *opts
 		newOpts.configValueHandling = cfgHandling
 		newOpts.fieldHandlingTree = child
+		newOpts.mergeKey = mergeKey
 		opts = &newOpts
 	}
 	return opts, nil
 }
 
+// pathOptsOverride selects the merge handling for the setting at path using
+// the last matching path pattern.
+func pathOptsOverride(opts *options, path Path) *options {
+	for i := len(opts.pathHandling) - 1; i >= 0; i-- {
+		h := opts.pathHandling[i]
+		if !h.pattern.matchPath(path) {
+			continue
+		}
+
+		if opts.configValueHandling != h.handling {
+			newOpts := *opts
+			newOpts.configValueHandling = h.handling
+			opts = &newOpts
+		}
+		break
+	}
+	return opts
+}
+
 func includeWildcard(child *fieldHandlingTree, parent *fieldHandlingTree) (*fieldHandlingTree, Error) {
 	if parent == nil {
 		return child, nil
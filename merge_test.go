@@ -879,6 +879,24 @@ func TestMergeFieldHandling(t *testing.T) {
 	}
 }
 
+func TestMergeFieldHandlingBracketPath(t *testing.T) {
+	c := MustNewFrom(map[string]interface{}{
+		"labels": map[string]interface{}{"app.kubernetes.io/hosts": []string{"a"}},
+	})
+	other := MustNewFrom(map[string]interface{}{
+		"labels": map[string]interface{}{"app.kubernetes.io/hosts": []string{"b"}},
+	})
+
+	err := c.Merge(other, PathSep("."), FieldAppendValues(`labels["app.kubernetes.io/hosts"]`))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	hosts, err := Get[[]string](c, `labels["app.kubernetes.io/hosts"]`, PathSep("."))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, hosts)
+}
+
 func TestMergeSquash(t *testing.T) {
 	type SubType struct{ B bool }
 	type SubInterface struct{ B interface{} }
@@ -1610,3 +1628,530 @@ func TestMergeGlobalArrConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestMergePatch(t *testing.T) {
+	type testCase struct {
+		options  []Option
+		in       []interface{}
+		expected map[string]interface{}
+	}
+
+	base := map[string]interface{}{
+		"output": map[string]interface{}{
+			"kafka":         map[string]interface{}{"hosts": []interface{}{"a", "b"}},
+			"elasticsearch": map[string]interface{}{"hosts": []interface{}{"c"}},
+		},
+		"paths": []interface{}{"a.log", "b.log"},
+		"name":  "beat",
+	}
+
+	cases := map[string]testCase{
+		"null removes key": testCase{
+			options: []Option{MergePatch},
+			in: []interface{}{
+				base,
+				map[string]interface{}{
+					"output": map[string]interface{}{"kafka": nil},
+					"name":   nil,
+				},
+			},
+			expected: map[string]interface{}{
+				"output": map[string]interface{}{
+					"elasticsearch": map[string]interface{}{"hosts": []interface{}{"c"}},
+				},
+				"paths": []interface{}{"a.log", "b.log"},
+			},
+		},
+		"arrays are replaced": testCase{
+			options: []Option{MergePatch},
+			in: []interface{}{
+				base,
+				map[string]interface{}{
+					"paths":  []interface{}{"c.log"},
+					"output": map[string]interface{}{"kafka": map[string]interface{}{"hosts": []interface{}{"x"}}},
+				},
+			},
+			expected: map[string]interface{}{
+				"output": map[string]interface{}{
+					"kafka":         map[string]interface{}{"hosts": []interface{}{"x"}},
+					"elasticsearch": map[string]interface{}{"hosts": []interface{}{"c"}},
+				},
+				"paths": []interface{}{"c.log"},
+				"name":  "beat",
+			},
+		},
+		"object replaces primitive and nested nulls are dropped": testCase{
+			options: []Option{MergePatch},
+			in: []interface{}{
+				base,
+				map[string]interface{}{
+					"name": map[string]interface{}{"first": "a", "last": nil},
+					"new":  map[string]interface{}{"x": nil, "y": 1},
+				},
+			},
+			expected: map[string]interface{}{
+				"output": map[string]interface{}{
+					"kafka":         map[string]interface{}{"hosts": []interface{}{"a", "b"}},
+					"elasticsearch": map[string]interface{}{"hosts": []interface{}{"c"}},
+				},
+				"paths": []interface{}{"a.log", "b.log"},
+				"name":  map[string]interface{}{"first": "a"},
+				"new":   map[string]interface{}{"y": uint64(1)},
+			},
+		},
+		"per field merge patch": testCase{
+			options: []Option{PathSep("."), FieldMergePatch("output")},
+			in: []interface{}{
+				base,
+				map[string]interface{}{
+					"output": map[string]interface{}{"kafka": nil},
+					"name":   "other",
+					"paths":  []interface{}{"c.log"},
+				},
+			},
+			expected: map[string]interface{}{
+				"output": map[string]interface{}{
+					"elasticsearch": map[string]interface{}{"hosts": []interface{}{"c"}},
+				},
+				"paths": []interface{}{"c.log", "b.log"},
+				"name":  "other",
+			},
+		},
+	}
+
+	for name, test := range cases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			cfg := New()
+			for _, in := range test.in {
+				err := cfg.Merge(in, test.options...)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			assertConfig(t, cfg, test.expected)
+		})
+	}
+}
+
+func TestMergePatchUnpack(t *testing.T) {
+	var settings struct {
+		Paths []string `config:"paths,mergepatch"`
+	}
+	settings.Paths = []string{"a.log", "b.log"}
+
+	cfg := MustNewFrom(map[string]interface{}{"paths": []string{"c.log"}})
+	assert.NoError(t, cfg.Unpack(&settings))
+	assert.Equal(t, []string{"c.log"}, settings.Paths)
+}
+
+func TestMergeByKey(t *testing.T) {
+	base := map[string]interface{}{
+		"modules": []interface{}{
+			map[string]interface{}{"module": "system", "period": "10s", "metricsets": []interface{}{"cpu", "memory"}},
+			map[string]interface{}{"module": "nginx", "period": "10s"},
+		},
+	}
+
+	overrides := map[string]interface{}{
+		"modules": []interface{}{
+			map[string]interface{}{"module": "redis", "period": "1s"},
+			map[string]interface{}{"module": "nginx", "period": "30s", "enabled": false},
+			map[string]interface{}{"module": "system", "metricsets": []interface{}{"load"}},
+		},
+	}
+
+	cfg := MustNewFrom(base, PathSep("."))
+	err := cfg.Merge(overrides, PathSep("."), FieldMergeByKey("modules", "module"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertConfig(t, cfg, map[string]interface{}{
+		"modules": []interface{}{
+			map[string]interface{}{"module": "system", "period": "10s", "metricsets": []interface{}{"load", "memory"}},
+			map[string]interface{}{"module": "nginx", "period": "30s", "enabled": false},
+			map[string]interface{}{"module": "redis", "period": "1s"},
+		},
+	})
+}
+
+func TestMergeByKeyNested(t *testing.T) {
+	base := map[string]interface{}{
+		"inputs": []interface{}{
+			map[string]interface{}{
+				"id": "a",
+				"processors": []interface{}{
+					map[string]interface{}{"name": "x", "value": 1},
+				},
+			},
+		},
+	}
+
+	overrides := map[string]interface{}{
+		"inputs": []interface{}{
+			map[string]interface{}{
+				"id": "a",
+				"processors": []interface{}{
+					map[string]interface{}{"name": "y", "value": 2},
+					map[string]interface{}{"name": "x", "value": 3},
+				},
+			},
+			"not an object",
+		},
+	}
+
+	cfg := MustNewFrom(base, PathSep("."))
+	err := cfg.Merge(overrides, PathSep("."),
+		FieldMergeByKey("inputs", "id"),
+		FieldMergeByKey("inputs.*.processors", "name"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertConfig(t, cfg, map[string]interface{}{
+		"inputs": []interface{}{
+			map[string]interface{}{
+				"id": "a",
+				"processors": []interface{}{
+					map[string]interface{}{"name": "x", "value": uint64(3)},
+					map[string]interface{}{"name": "y", "value": uint64(2)},
+				},
+			},
+			"not an object",
+		},
+	})
+}
+
+func TestMergeByKeyUnpack(t *testing.T) {
+	type module struct {
+		Module     string   `config:"module"`
+		Period     string   `config:"period"`
+		Metricsets []string `config:"metricsets"`
+	}
+
+	var settings struct {
+		Modules []module `config:"modules,mergekey=module"`
+	}
+	settings.Modules = []module{
+		{Module: "system", Period: "10s", Metricsets: []string{"cpu", "memory"}},
+		{Module: "nginx", Period: "10s"},
+	}
+
+	cfg := MustNewFrom(map[string]interface{}{
+		"modules": []interface{}{
+			map[string]interface{}{"module": "redis", "period": "1s"},
+			map[string]interface{}{"module": "system", "metricsets": []interface{}{"load"}},
+		},
+	})
+	assert.NoError(t, cfg.Unpack(&settings))
+	assert.Equal(t, []module{
+		{Module: "system", Period: "10s", Metricsets: []string{"load", "memory"}},
+		{Module: "nginx", Period: "10s"},
+		{Module: "redis", Period: "1s"},
+	}, settings.Modules)
+}
+
+func TestMergeDetectConflicts(t *testing.T) {
+	base := map[string]interface{}{
+		"output": map[string]interface{}{
+			"hosts":   []interface{}{"a"},
+			"timeout": 10,
+		},
+		"name": "beat",
+	}
+
+	cases := map[string]struct {
+		in      map[string]interface{}
+		opts    []Option
+		path    string
+		message string
+	}{
+		"merge new keys": {
+			in: map[string]interface{}{
+				"output": map[string]interface{}{"ssl": map[string]interface{}{"enabled": true}},
+				"tags":   []interface{}{"x"},
+			},
+		},
+		"same values": {
+			in: map[string]interface{}{
+				"output": map[string]interface{}{"hosts": []interface{}{"a"}, "timeout": int64(10)},
+				"name":   "beat",
+			},
+		},
+		"scalar differs": {
+			in:      map[string]interface{}{"name": "other"},
+			path:    "name",
+			message: "conflicting values 'beat' (source:'base.yml') and 'other' accessing 'name' (source:'team.yml')",
+		},
+		"array element differs": {
+			in:      map[string]interface{}{"output": map[string]interface{}{"hosts": []interface{}{"b"}}},
+			path:    "output.hosts.0",
+			message: "conflicting values 'a' (source:'base.yml') and 'b' accessing 'output.hosts.0' (source:'team.yml')",
+		},
+		"type differs": {
+			in:      map[string]interface{}{"output": "console"},
+			path:    "output",
+			message: "conflicting values object (source:'base.yml') and 'console' accessing 'output' (source:'team.yml')",
+		},
+		"replaced object differs": {
+			in:      map[string]interface{}{"output": map[string]interface{}{"hosts": []interface{}{"a"}}},
+			opts:    []Option{ReplaceValues},
+			path:    "output",
+			message: "conflicting values object (source:'base.yml') and object accessing 'output' (source:'team.yml')",
+		},
+		"replaced array differs": {
+			in:      map[string]interface{}{"output": map[string]interface{}{"hosts": []interface{}{"a", "b"}}},
+			opts:    []Option{ReplaceArrValues},
+			path:    "output.hosts",
+			message: "conflicting values array (source:'base.yml') and array accessing 'output.hosts' (source:'team.yml')",
+		},
+		"replaced array path differs": {
+			in:      map[string]interface{}{"output": map[string]interface{}{"hosts": []interface{}{"b"}}},
+			opts:    []Option{PathReplaceValues(MustCompilePathPattern("output.hosts"))},
+			path:    "output.hosts",
+			message: "conflicting values array (source:'base.yml') and array accessing 'output.hosts' (source:'team.yml')",
+		},
+		"replaced same values": {
+			in: map[string]interface{}{
+				"output": map[string]interface{}{"hosts": []interface{}{"a"}, "timeout": int64(10)},
+				"name":   "beat",
+			},
+			opts: []Option{ReplaceValues},
+		},
+		"replaced array allowed": {
+			in:   map[string]interface{}{"output": map[string]interface{}{"hosts": []interface{}{"b"}}},
+			opts: []Option{ReplaceArrValues, AllowOverrides("output.hosts")},
+		},
+		"override allowed": {
+			in:   map[string]interface{}{"name": "other", "output": map[string]interface{}{"timeout": 30}},
+			opts: []Option{AllowOverrides("name", "*.timeout")},
+		},
+		"allowed parent": {
+			in:   map[string]interface{}{"output": map[string]interface{}{"hosts": []interface{}{"b"}}},
+			opts: []Option{AllowOverrides("output")},
+		},
+		"override allowed by pattern": {
+			in:   map[string]interface{}{"name": "other", "output": map[string]interface{}{"timeout": 30}},
+			opts: []Option{AllowOverridePatterns(MustCompilePathPattern("name"), MustCompilePathPattern("*.timeout"))},
+		},
+		"allowed parent pattern": {
+			in:   map[string]interface{}{"output": map[string]interface{}{"hosts": []interface{}{"b"}}},
+			opts: []Option{AllowOverridePatterns(MustCompilePathPattern("output.**"))},
+		},
+		"pattern does not match nested settings": {
+			in:      map[string]interface{}{"output": map[string]interface{}{"hosts": []interface{}{"b"}}},
+			opts:    []Option{AllowOverridePatterns(MustCompilePathPattern("output"))},
+			path:    "output.hosts.0",
+			message: "conflicting values 'a' (source:'base.yml') and 'b' accessing 'output.hosts.0' (source:'team.yml')",
+		},
+	}
+
+	for name, test := range cases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			cfg := MustNewFrom(base, MetaData(Meta{Source: "base.yml"}))
+
+			opts := append([]Option{DetectConflicts, MetaData(Meta{Source: "team.yml"})}, test.opts...)
+			err := cfg.Merge(test.in, opts...)
+			if test.path == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			if assert.Error(t, err) {
+				assert.Equal(t, ErrConflict, err.(Error).Reason())
+				assert.Equal(t, test.path, err.(Error).Path())
+				assert.Equal(t, test.message, err.Error())
+			}
+		})
+	}
+}
+
+func TestMergeDetectConflictsQuotedKeys(t *testing.T) {
+	base := map[string]interface{}{
+		"labels": map[string]interface{}{"app.kubernetes.io/name": "beat"},
+	}
+	update := map[string]interface{}{
+		"labels": map[string]interface{}{"app.kubernetes.io/name": "agent"},
+	}
+
+	cases := map[string]struct {
+		allow   []string
+		allowed bool
+	}{
+		"not allowed":        {},
+		"quoted name":        {allow: []string{`labels["app.kubernetes.io/name"]`}, allowed: true},
+		"parent":             {allow: []string{"labels"}, allowed: true},
+		"wildcard":           {allow: []string{"labels.*"}, allowed: true},
+		"split name":         {allow: []string{"labels.app"}},
+		"invalid is ignored": {allow: []string{"labels["}},
+	}
+
+	for name, test := range cases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			cfg := MustNewFrom(base)
+			err := cfg.Merge(update, DetectConflicts, AllowOverrides(test.allow...))
+			if test.allowed {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Equal(t, ErrConflict, err.(Error).Reason())
+				assert.Equal(t, `labels["app.kubernetes.io/name"]`, err.(Error).Path())
+			}
+		})
+	}
+}
+
+func TestMergePathPatternHandling(t *testing.T) {
+	base := map[string]interface{}{
+		"processors": []interface{}{"a"},
+		"inputs": []interface{}{
+			map[string]interface{}{
+				"paths":      []interface{}{"x.log"},
+				"processors": []interface{}{"b"},
+			},
+		},
+	}
+
+	overrides := map[string]interface{}{
+		"processors": []interface{}{"c"},
+		"inputs": []interface{}{
+			map[string]interface{}{
+				"paths":      []interface{}{"y.log"},
+				"processors": []interface{}{"d"},
+			},
+		},
+	}
+
+	cfg := MustNewFrom(base)
+	err := cfg.Merge(overrides,
+		PathAppendValues(MustCompilePathPattern("**.processors")),
+		PathReplaceValues(MustCompilePathPattern("inputs[*].paths")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertConfig(t, cfg, map[string]interface{}{
+		"processors": []interface{}{"a", "c"},
+		"inputs": []interface{}{
+			map[string]interface{}{
+				"paths":      []interface{}{"y.log"},
+				"processors": []interface{}{"b", "d"},
+			},
+		},
+	})
+}
+
+func TestMergePathPatternQuotedKeys(t *testing.T) {
+	cfg := MustNewFrom(map[string]interface{}{
+		"labels": map[string]interface{}{
+			"a.b": []interface{}{1},
+			"c.d": []interface{}{1},
+		},
+	})
+	err := cfg.Merge(map[string]interface{}{
+		"labels": map[string]interface{}{
+			"a.b": []interface{}{2},
+			"c.d": []interface{}{2},
+		},
+	},
+		PathAppendValues(MustCompilePathPattern(`labels["a.b"]`)),
+		PathAppendValues(MustCompilePathPattern("labels.c.d")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	appended, err := Get[[]int](cfg, `labels["a.b"]`, PathSep("."))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, appended)
+
+	merged, err := Get[[]int](cfg, `labels["c.d"]`, PathSep("."))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2}, merged)
+}
+
+func TestMergePathPatternPrecedence(t *testing.T) {
+	cfg := MustNewFrom(map[string]interface{}{"a": []interface{}{1}, "b": []interface{}{1}})
+	err := cfg.Merge(map[string]interface{}{"a": []interface{}{2}, "b": []interface{}{2}},
+		PathAppendValues(MustCompilePathPattern("*")),
+		PathPrependValues(MustCompilePathPattern("b")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertConfig(t, cfg, map[string]interface{}{
+		"a": []interface{}{uint64(1), uint64(2)},
+		"b": []interface{}{uint64(2), uint64(1)},
+	})
+}
+
+func TestMergeKeysWithPathSyntax(t *testing.T) {
+	in := map[string]interface{}{
+		"opening": map[string]interface{}{
+			"10:30": "x",
+			"12:00": "y",
+		},
+		"ops": map[string]interface{}{
+			"+": "plus",
+			"-": "minus",
+		},
+		"x[]":   "suffix",
+		"list":  []interface{}{"a", "b"},
+		"1:":    "open slice",
+		"a.b:c": 1,
+	}
+
+	c, err := NewFrom(in, PathSep("."))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var out map[string]interface{}
+	if !assert.NoError(t, c.Unpack(&out)) {
+		return
+	}
+	assert.Equal(t, map[string]interface{}{
+		"opening": map[string]interface{}{"10:30": "x", "12:00": "y"},
+		"ops":     map[string]interface{}{"+": "plus", "-": "minus"},
+		"x[]":     "suffix",
+		"list":    []interface{}{"a", "b"},
+		"1:":      "open slice",
+		"a":       map[string]interface{}{"b:c": uint64(1)},
+	}, out)
+}
+
+func TestMergeKeysWithBrackets(t *testing.T) {
+	in := map[string]interface{}{
+		"a[0]":   "zero",
+		`a["b"]`: "quoted",
+		"nested": map[string]interface{}{
+			"x[1:2]": true,
+		},
+	}
+
+	for name, opts := range map[string][]Option{
+		"no separator": nil,
+		"separator":    {PathSep(".")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, err := NewFrom(in, opts...)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.ElementsMatch(t, []string{"a[0]", `a["b"]`, "nested"}, c.GetFields())
+
+			var out map[string]interface{}
+			if assert.NoError(t, c.Unpack(&out)) {
+				assert.Equal(t, in, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package migrate upgrades versioned configurations by applying ordered
+// migration steps before the configuration is unpacked.
+//
+// The version of a configuration is read from the setting `config_version`.
+// Configurations without version are treated as version 0, such that all
+// registered steps are applied. The changes applied by each step are
+// recorded in a Report for logging.
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	ucfg "github.com/elastic/go-ucfg"
+	"github.com/elastic/go-ucfg/diff"
+)
+
+// DefaultVersionKey is the setting holding the configuration version.
+const DefaultVersionKey = "config_version"
+
+var (
+	// ErrStepOrder indicates a migration step not being registered with a
+	// version greater than the version of the previous step.
+	ErrStepOrder = errors.New("migration steps must be registered in increasing version order")
+
+	// ErrUnsupportedVersion indicates the configuration version being newer
+	// than the latest registered migration step.
+	ErrUnsupportedVersion = errors.New("unsupported config version")
+)
+
+// Func modifies a configuration as part of a migration step. The options
+// passed configure the access to the configuration. Paths are always
+// separated by '.'.
+type Func func(cfg *ucfg.Config, opts ...ucfg.Option) error
+
+// Migrator applies registered migration steps to configurations.
+type Migrator struct {
+	versionKey string
+	configOpts []ucfg.Option
+	steps      []step
+}
+
+type step struct {
+	version     uint64
+	description string
+	ops         []Func
+}
+
+// Option configures a Migrator.
+type Option func(*Migrator)
+
+// Report records the migration of a configuration.
+type Report struct {
+	From  uint64
+	To    uint64
+	Steps []StepReport
+}
+
+// StepReport records the changes applied by a single migration step. The
+// change of the version setting is not recorded.
+type StepReport struct {
+	From        uint64
+	To          uint64
+	Description string
+	Changes     diff.Changes
+}
+
+// VersionKey sets the setting holding the configuration version. The
+// default is DefaultVersionKey.
+func VersionKey(key string) Option {
+	return func(m *Migrator) {
+		m.versionKey = key
+	}
+}
+
+// ConfigOptions sets the options used to access the configurations (e.g.
+// Env, Resolve, or ResolveEnv for resolving variables).
+func ConfigOptions(opts ...ucfg.Option) Option {
+	return func(m *Migrator) {
+		m.configOpts = append(m.configOpts, opts...)
+	}
+}
+
+// New creates a Migrator without migration steps.
+func New(opts ...Option) *Migrator {
+	m := &Migrator{versionKey: DefaultVersionKey}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Register adds a migration step upgrading configurations to version. The
+// operations are applied in order. Steps must be registered in increasing
+// version order, starting with version 1.
+func (m *Migrator) Register(version uint64, description string, ops ...Func) error {
+	if version <= m.Latest() {
+		return fmt.Errorf("can not register version %v after version %v: %w",
+			version, m.Latest(), ErrStepOrder)
+	}
+
+	m.steps = append(m.steps, step{version: version, description: description, ops: ops})
+	return nil
+}
+
+// MustRegister adds a migration step like Register, but panics if the step
+// can not be registered.
+func (m *Migrator) MustRegister(version uint64, description string, ops ...Func) *Migrator {
+	if err := m.Register(version, description, ops...); err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Latest returns the version of the last registered migration step, or 0 if
+// no step has been registered.
+func (m *Migrator) Latest() uint64 {
+	if len(m.steps) == 0 {
+		return 0
+	}
+	return m.steps[len(m.steps)-1].version
+}
+
+// Migrate applies all migration steps with a version greater than the
+// version of cfg. The steps are applied to a copy of cfg, which is returned
+// with the version setting being updated to the latest applied version. cfg
+// is not modified.
+//
+// Migrate returns an error wrapping ErrUnsupportedVersion if the version of
+// cfg is newer than the latest registered step.
+func (m *Migrator) Migrate(cfg *ucfg.Config) (*ucfg.Config, Report, error) {
+	opts := m.options()
+
+	version, err := ucfg.GetOr[uint64](cfg, m.versionKey, 0, opts...)
+	if err != nil {
+		return nil, Report{}, fmt.Errorf("failed to read '%v': %w", m.versionKey, err)
+	}
+
+	report := Report{From: version, To: version}
+	if version > m.Latest() {
+		return nil, report, fmt.Errorf("version %v is newer than version %v: %w",
+			version, m.Latest(), ErrUnsupportedVersion)
+	}
+
+	out := cfg.Clone()
+	for _, s := range m.steps {
+		if s.version <= version {
+			continue
+		}
+
+		before := out.Clone()
+		for _, op := range s.ops {
+			if err := op(out, opts...); err != nil {
+				return nil, report, fmt.Errorf("migration to version %v (%v) failed: %w",
+					s.version, s.description, err)
+			}
+		}
+
+		changes, err := diff.CompareValues(before, out, diff.ConfigOptions(opts...))
+		if err != nil {
+			return nil, report, err
+		}
+		if err := ucfg.Set(out, m.versionKey, s.version, opts...); err != nil {
+			return nil, report, err
+		}
+
+		report.Steps = append(report.Steps, StepReport{
+			From:        version,
+			To:          s.version,
+			Description: s.description,
+			Changes:     m.filterChanges(changes),
+		})
+		version = s.version
+		report.To = version
+	}
+
+	return out, report, nil
+}
+
+// Unpack migrates cfg and unpacks the migrated configuration into to. cfg
+// is not modified.
+func (m *Migrator) Unpack(cfg *ucfg.Config, to interface{}, opts ...ucfg.Option) (Report, error) {
+	migrated, report, err := m.Migrate(cfg)
+	if err != nil {
+		return report, err
+	}
+	return report, migrated.Unpack(to, opts...)
+}
+
+func (m *Migrator) options() []ucfg.Option {
+	return append([]ucfg.Option{ucfg.PathSep(".")}, m.configOpts...)
+}
+
+// filterChanges removes unchanged settings and the version setting from
+// changes.
+func (m *Migrator) filterChanges(changes diff.Changes) diff.Changes {
+	var filtered diff.Changes
+	for _, change := range changes.Filter(diff.Add, diff.Remove, diff.Modify) {
+		if change.Key != m.versionKey {
+			filtered = append(filtered, change)
+		}
+	}
+	return filtered
+}
+
+// HasChanged returns true if any migration step has been applied.
+func (r Report) HasChanged() bool {
+	return len(r.Steps) > 0
+}
+
+// String formats the report for logging, listing the changes of each step
+// like a unified diff. Values of secret keys are masked.
+func (r Report) String() string {
+	if !r.HasChanged() {
+		return fmt.Sprintf("config version %v is up to date", r.From)
+	}
+
+	lines := []string{fmt.Sprintf("migrated config from version %v to %v", r.From, r.To)}
+	for _, s := range r.Steps {
+		lines = append(lines, s.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// String formats the changes of the step like a unified diff.
+func (s StepReport) String() string {
+	header := fmt.Sprintf("version %v -> %v: %v", s.From, s.To, s.Description)
+	if len(s.Changes) == 0 {
+		return header
+	}
+	return header + "\n" + s.Changes.String()
+}
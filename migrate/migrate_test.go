@@ -0,0 +1,210 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package migrate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ucfg "github.com/elastic/go-ucfg"
+	"github.com/elastic/go-ucfg/diff"
+)
+
+func testMigrator() *Migrator {
+	return New().
+		MustRegister(1, "rename output.es", Rename("output.es", "output.elasticsearch")).
+		MustRegister(2, "convert timeout to duration string",
+			Convert("output.elasticsearch.timeout", func(v interface{}) (interface{}, error) {
+				n, ok := v.(uint64)
+				if !ok {
+					return nil, errors.New("expected number")
+				}
+				return (time.Duration(n) * time.Second).String(), nil
+			})).
+		MustRegister(3, "drop deprecated settings", Drop("output.elasticsearch.compat"))
+}
+
+func TestMigrate(t *testing.T) {
+	cfg := ucfg.MustNewFrom(map[string]interface{}{
+		"output.es.hosts":   []string{"localhost:9200"},
+		"output.es.timeout": 30,
+		"output.es.compat":  true,
+	}, ucfg.PathSep("."))
+
+	migrated, report, err := testMigrator().Migrate(cfg)
+	require.NoError(t, err)
+
+	expected := ucfg.MustNewFrom(map[string]interface{}{
+		"config_version":               3,
+		"output.elasticsearch.hosts":   []string{"localhost:9200"},
+		"output.elasticsearch.timeout": "30s",
+	}, ucfg.PathSep("."))
+	assert.True(t, expected.Equal(migrated), "migrated: %v", report)
+
+	// the input is not modified
+	assert.True(t, cfg.HasField("output"))
+	assert.False(t, cfg.HasField("config_version"))
+
+	assert.Equal(t, uint64(0), report.From)
+	assert.Equal(t, uint64(3), report.To)
+	require.Len(t, report.Steps, 3)
+
+	keys := func(changes diff.Changes) []string {
+		var keys []string
+		for _, change := range changes {
+			keys = append(keys, change.Type.String()+change.Key)
+		}
+		return keys
+	}
+	assert.Equal(t, []string{
+		"+output.elasticsearch.compat",
+		"+output.elasticsearch.hosts.0",
+		"+output.elasticsearch.timeout",
+		"-output.es.compat",
+		"-output.es.hosts.0",
+		"-output.es.timeout",
+	}, keys(report.Steps[0].Changes))
+	assert.Equal(t, []string{"~output.elasticsearch.timeout"}, keys(report.Steps[1].Changes))
+	assert.Equal(t, []string{"-output.elasticsearch.compat"}, keys(report.Steps[2].Changes))
+
+	assert.Equal(t, uint64(1), report.Steps[1].From)
+	assert.Equal(t, uint64(2), report.Steps[1].To)
+	assert.Equal(t, "convert timeout to duration string", report.Steps[1].Description)
+}
+
+func TestMigrateFromVersion(t *testing.T) {
+	cfg := ucfg.MustNewFrom(map[string]interface{}{
+		"config_version":               "2",
+		"output.elasticsearch.timeout": "30s",
+		"output.elasticsearch.compat":  true,
+	}, ucfg.PathSep("."))
+
+	migrated, report, err := testMigrator().Migrate(cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(2), report.From)
+	assert.Equal(t, uint64(3), report.To)
+	require.Len(t, report.Steps, 1)
+	assert.Equal(t, "drop deprecated settings", report.Steps[0].Description)
+
+	v, err := ucfg.Get[uint64](migrated, "config_version")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), v)
+	timeout, err := migrated.String("output.elasticsearch.timeout", -1, ucfg.PathSep("."))
+	require.NoError(t, err)
+	assert.Equal(t, "30s", timeout)
+}
+
+func TestMigrateUpToDate(t *testing.T) {
+	cfg := ucfg.MustNewFrom(map[string]interface{}{
+		"config_version": 3,
+		"output.es":      "ignored",
+	}, ucfg.PathSep("."))
+
+	migrated, report, err := testMigrator().Migrate(cfg)
+	require.NoError(t, err)
+	assert.False(t, report.HasChanged())
+	assert.True(t, cfg.Equal(migrated))
+	assert.Equal(t, "config version 3 is up to date", report.String())
+}
+
+func TestMigrateUnsupportedVersion(t *testing.T) {
+	cfg := ucfg.MustNewFrom(map[string]interface{}{"config_version": 4})
+
+	_, _, err := testMigrator().Migrate(cfg)
+	assert.True(t, errors.Is(err, ErrUnsupportedVersion), "unexpected error: %v", err)
+}
+
+func TestMigrateStepFailure(t *testing.T) {
+	cfg := ucfg.MustNewFrom(map[string]interface{}{
+		"output.es.timeout": "30s",
+	}, ucfg.PathSep("."))
+
+	_, report, err := testMigrator().Migrate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "migration to version 2")
+	assert.Len(t, report.Steps, 1)
+}
+
+func TestMigrateVersionKey(t *testing.T) {
+	m := New(VersionKey("meta.version")).
+		MustRegister(1, "rename", Rename("a", "b"))
+
+	cfg := ucfg.MustNewFrom(map[string]interface{}{"a": 1})
+	migrated, report, err := m.Migrate(cfg)
+	require.NoError(t, err)
+	require.Len(t, report.Steps, 1)
+	assert.Len(t, report.Steps[0].Changes, 2)
+
+	v, err := ucfg.Get[uint64](migrated, "meta.version", ucfg.PathSep("."))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), v)
+}
+
+func TestRegisterOrder(t *testing.T) {
+	m := New()
+	assert.True(t, errors.Is(m.Register(0, "zero"), ErrStepOrder))
+	require.NoError(t, m.Register(2, "two"))
+	assert.True(t, errors.Is(m.Register(2, "again"), ErrStepOrder))
+	assert.True(t, errors.Is(m.Register(1, "older"), ErrStepOrder))
+	assert.Equal(t, uint64(2), m.Latest())
+}
+
+func TestMigrateUnpack(t *testing.T) {
+	cfg := ucfg.MustNewFrom(map[string]interface{}{
+		"output.es.hosts":   []string{"localhost:9200"},
+		"output.es.timeout": 30,
+	}, ucfg.PathSep("."))
+
+	var settings struct {
+		Version uint64 `config:"config_version"`
+		Output  struct {
+			Elasticsearch struct {
+				Hosts   []string      `config:"hosts"`
+				Timeout time.Duration `config:"timeout"`
+			} `config:"elasticsearch"`
+		} `config:"output"`
+	}
+
+	report, err := testMigrator().Unpack(cfg, &settings)
+	require.NoError(t, err)
+	assert.True(t, report.HasChanged())
+	assert.Equal(t, uint64(3), settings.Version)
+	assert.Equal(t, []string{"localhost:9200"}, settings.Output.Elasticsearch.Hosts)
+	assert.Equal(t, 30*time.Second, settings.Output.Elasticsearch.Timeout)
+}
+
+func TestReportString(t *testing.T) {
+	cfg := ucfg.MustNewFrom(map[string]interface{}{
+		"output.es.password": "secret",
+	}, ucfg.PathSep("."))
+
+	_, report, err := testMigrator().Migrate(cfg)
+	require.NoError(t, err)
+
+	s := report.String()
+	assert.True(t, strings.HasPrefix(s, "migrated config from version 0 to 3\nversion 0 -> 1: rename output.es\n"), s)
+	assert.Contains(t, s, "+ output.elasticsearch.password: <hidden>")
+	assert.NotContains(t, s, "secret")
+	assert.Contains(t, s, "version 1 -> 2: convert timeout to duration string\n")
+}
@@ -0,0 +1,192 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	ucfg "github.com/elastic/go-ucfg"
+)
+
+// ErrExists indicates the target setting of an operation already being
+// present in the configuration.
+var ErrExists = errors.New("setting already exists")
+
+// Rename moves the setting from to the path to. The setting keeps its meta
+// data, and variables are moved unresolved. Rename does nothing if from does
+// not exist, and fails with ErrExists if to already exists.
+func Rename(from, to string) Func {
+	return func(cfg *ucfg.Config, opts ...ucfg.Option) error {
+		if has, err := cfg.Has(from, -1, opts...); err != nil || !has {
+			return err
+		}
+		if err := checkAbsent(cfg, to, opts); err != nil {
+			return err
+		}
+		return cfg.Move(from, to, opts...)
+	}
+}
+
+// Convert replaces the value of the setting at path with the value returned
+// by fn. Objects and arrays are passed to fn as map[string]interface{} and
+// []interface{}, with variables being resolved. The setting keeps its meta
+// data. Convert does nothing if path does not exist.
+func Convert(path string, fn func(v interface{}) (interface{}, error)) Func {
+	return func(cfg *ucfg.Config, opts ...ucfg.Option) error {
+		has, err := cfg.Has(path, -1, opts...)
+		if err != nil || !has {
+			return err
+		}
+
+		v, err := ucfg.Get[interface{}](cfg, path, opts...)
+		if err != nil {
+			return err
+		}
+		v, err = fn(v)
+		if err != nil {
+			return fmt.Errorf("failed to convert '%v': %w", path, err)
+		}
+		meta, err := cfg.MetaOf(path, -1, opts...)
+		if err != nil {
+			return err
+		}
+		return set(cfg, path, v, meta, opts)
+	}
+}
+
+// Split replaces the setting at from with the settings returned by fn. The
+// keys of the map returned by fn are the paths of the new settings, relative
+// to the configuration root. The new settings keep the meta data of from.
+// Split does nothing if from does not exist.
+func Split(from string, fn func(v interface{}) (map[string]interface{}, error)) Func {
+	return func(cfg *ucfg.Config, opts ...ucfg.Option) error {
+		has, err := cfg.Has(from, -1, opts...)
+		if err != nil || !has {
+			return err
+		}
+
+		v, err := ucfg.Get[interface{}](cfg, from, opts...)
+		if err != nil {
+			return err
+		}
+		fields, err := fn(v)
+		if err != nil {
+			return fmt.Errorf("failed to split '%v': %w", from, err)
+		}
+
+		meta, err := cfg.MetaOf(from, -1, opts...)
+		if err != nil {
+			return err
+		}
+		if _, err := cfg.Remove(from, -1, opts...); err != nil {
+			return err
+		}
+		for _, path := range sortedKeys(fields) {
+			if err := set(cfg, path, fields[path], meta, opts); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Merge replaces the settings at the paths from with the single setting at
+// to, holding the value returned by fn. fn is called with the values of the
+// settings present, indexed by their path. Merge does nothing if none of
+// the settings exists.
+func Merge(from []string, to string, fn func(vals map[string]interface{}) (interface{}, error)) Func {
+	return func(cfg *ucfg.Config, opts ...ucfg.Option) error {
+		vals := make(map[string]interface{}, len(from))
+		var meta *ucfg.Meta
+		for _, path := range from {
+			has, err := cfg.Has(path, -1, opts...)
+			if err != nil {
+				return err
+			}
+			if !has {
+				continue
+			}
+
+			if vals[path], err = ucfg.Get[interface{}](cfg, path, opts...); err != nil {
+				return err
+			}
+			if meta == nil {
+				if meta, err = cfg.MetaOf(path, -1, opts...); err != nil {
+					return err
+				}
+			}
+		}
+		if len(vals) == 0 {
+			return nil
+		}
+
+		v, err := fn(vals)
+		if err != nil {
+			return fmt.Errorf("failed to merge into '%v': %w", to, err)
+		}
+
+		for _, path := range from {
+			if _, err := cfg.Remove(path, -1, opts...); err != nil {
+				return err
+			}
+		}
+		return set(cfg, to, v, meta, opts)
+	}
+}
+
+// Drop removes the settings at the given paths. Missing settings are
+// ignored.
+func Drop(paths ...string) Func {
+	return func(cfg *ucfg.Config, opts ...ucfg.Option) error {
+		for _, path := range paths {
+			if _, err := cfg.Remove(path, -1, opts...); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func checkAbsent(cfg *ucfg.Config, path string, opts []ucfg.Option) error {
+	has, err := cfg.Has(path, -1, opts...)
+	if err != nil {
+		return err
+	}
+	if has {
+		return fmt.Errorf("can not write '%v': %w", path, ErrExists)
+	}
+	return nil
+}
+
+func set(cfg *ucfg.Config, path string, v interface{}, meta *ucfg.Meta, opts []ucfg.Option) error {
+	if meta != nil {
+		opts = append(opts[:len(opts):len(opts)], ucfg.MetaData(*meta))
+	}
+	return ucfg.Set(cfg, path, v, opts...)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,140 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ucfg "github.com/elastic/go-ucfg"
+)
+
+var dotted = []ucfg.Option{ucfg.PathSep(".")}
+
+func TestRename(t *testing.T) {
+	cfg := ucfg.MustNewFrom(map[string]interface{}{
+		"a.b": 1,
+		"c":   "${a.b}",
+	}, ucfg.PathSep("."), ucfg.VarExp, ucfg.MetaData(ucfg.Meta{Source: "old.yml"}))
+
+	// variables are moved unresolved
+	require.NoError(t, Rename("c", "d")(cfg, dotted...))
+	d, err := cfg.String("d", -1, dotted...)
+	require.NoError(t, err)
+	assert.Equal(t, "1", d)
+
+	require.NoError(t, Rename("a.b", "x.y")(cfg, dotted...))
+	require.NoError(t, Rename("missing", "other")(cfg, dotted...))
+
+	has, err := cfg.Has("a.b", -1, dotted...)
+	require.NoError(t, err)
+	assert.False(t, has)
+	assert.False(t, cfg.HasField("other"))
+
+	meta, err := cfg.MetaOf("x.y", -1, dotted...)
+	require.NoError(t, err)
+	assert.Equal(t, "old.yml", meta.Source)
+}
+
+func TestRenameExists(t *testing.T) {
+	cfg := ucfg.MustNewFrom(map[string]interface{}{"a": 1, "b": 2})
+
+	err := Rename("a", "b")(cfg, dotted...)
+	assert.True(t, errors.Is(err, ErrExists), "unexpected error: %v", err)
+	assert.True(t, cfg.HasField("a"))
+}
+
+func TestConvert(t *testing.T) {
+	cfg := ucfg.MustNewFrom(map[string]interface{}{"hosts": "a,b"},
+		ucfg.MetaData(ucfg.Meta{Source: "beat.yml"}))
+
+	split := func(v interface{}) (interface{}, error) {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string, got %T", v)
+		}
+		return strings.Split(s, ","), nil
+	}
+
+	require.NoError(t, Convert("hosts", split)(cfg, dotted...))
+	require.NoError(t, Convert("missing", split)(cfg, dotted...))
+	assert.Equal(t, []string{"a", "b"}, ucfg.MustGet[[]string](cfg, "hosts"))
+
+	meta, err := cfg.MetaOf("hosts", -1, dotted...)
+	require.NoError(t, err)
+	assert.Equal(t, "beat.yml", meta.Source)
+
+	err = Convert("hosts", split)(cfg, dotted...)
+	assert.EqualError(t, err, "failed to convert 'hosts': expected string, got []interface {}")
+}
+
+func TestSplit(t *testing.T) {
+	cfg := ucfg.MustNewFrom(map[string]interface{}{"host": "localhost:9200"})
+
+	op := Split("host", func(v interface{}) (map[string]interface{}, error) {
+		parts := strings.SplitN(v.(string), ":", 2)
+		return map[string]interface{}{
+			"server.host": parts[0],
+			"server.port": parts[1],
+		}, nil
+	})
+	require.NoError(t, op(cfg, dotted...))
+
+	expected := ucfg.MustNewFrom(map[string]interface{}{
+		"server.host": "localhost",
+		"server.port": "9200",
+	}, ucfg.PathSep("."))
+	assert.True(t, expected.Equal(cfg))
+}
+
+func TestMerge(t *testing.T) {
+	cfg := ucfg.MustNewFrom(map[string]interface{}{
+		"username": "elastic",
+		"other":    true,
+	})
+
+	op := Merge([]string{"username", "password"}, "auth", func(vals map[string]interface{}) (interface{}, error) {
+		return vals, nil
+	})
+	require.NoError(t, op(cfg, dotted...))
+
+	expected := ucfg.MustNewFrom(map[string]interface{}{
+		"auth.username": "elastic",
+		"other":         true,
+	}, ucfg.PathSep("."))
+	assert.True(t, expected.Equal(cfg))
+
+	// no-op if no setting is present
+	require.NoError(t, op(cfg, dotted...))
+	assert.True(t, expected.Equal(cfg))
+}
+
+func TestDrop(t *testing.T) {
+	cfg := ucfg.MustNewFrom(map[string]interface{}{
+		"a.b": 1,
+		"a.c": 2,
+	}, ucfg.PathSep("."))
+
+	require.NoError(t, Drop("a.b", "missing", "x.y")(cfg, dotted...))
+	assert.Equal(t, []string{"a.c"}, cfg.FlattenedKeys(dotted...))
+}
@@ -20,6 +20,8 @@ package ucfg
 import (
 	"fmt"
 	"os"
+	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/elastic/go-ucfg/parse"
@@ -34,22 +36,50 @@ const defaultMaxIdx = 1024
 type Option func(*options)
 
 type options struct {
-	tag          string
-	validatorTag string
-	noValidate   bool
-	pathSep      string
-	escapePath   bool
-	meta         *Meta
-	env          []*Config
-	resolvers    []func(name string) (string, parse.Config, error)
-	varexp       bool
-	noParse      bool
+	tag           string
+	validatorTag  string
+	deprecatedTag string
+	validators    *ValidatorRegistry
+	noValidate    bool
+	pathSep       string
+	escapePath    bool
+	meta          *Meta
+	env           []*Config
+	resolvers     []func(name string) (string, parse.Config, error)
+	varexp        bool
+	// resolve variable expansions when comparing or hashing configurations
+	resolveDynamic bool
+	postOrder      bool
+	noParse        bool
+
+	// encode all types implementing encoding.TextMarshaler as strings
+	marshalText bool
 
 	maxIdx        int64 // Max index field value allowed
 	enableNumKeys bool  // Enables numeric keys, example "123"
 
 	configValueHandling configHandling
 	fieldHandlingTree   *fieldHandlingTree
+	pathHandling        []pathHandling
+
+	// name of the field identifying array elements if arrays are merged by key
+	mergeKey string
+
+	// fail merging if a setting is overwritten with a different value, unless
+	// the setting path matches allowOverridePatterns
+	detectConflicts       bool
+	allowOverridePatterns []PathPattern
+
+	// name of the field selecting the implementation of interface types
+	// registered via RegisterType
+	discriminator string
+
+	// select implementation of registered interface types by the single key
+	// of the config object instead of the discriminator field
+	typeKey bool
+
+	// converters registered via Converter, indexed by target type
+	converters map[reflect.Type]converterFunc
 
 	// temporary cache of parsed splice values for lifetime of call to
 	// Unpack/Pack/Get/...
@@ -59,10 +89,15 @@ type options struct {
 	configuredFields *fieldSet
 
 	ignoreCommas bool
+
+	// callback reporting the use of deprecated settings when unpacking
+	onDeprecated func(Deprecation)
 }
 
 type valueCache map[string]spliceValue
 
+type converterFunc func(v interface{}, path string) (reflect.Value, error)
+
 // specific API on top of Config to handle adjusting merging behavior per fields
 type fieldHandlingTree Config
 
@@ -102,6 +137,34 @@ func ValidatorTag(tag string) Option {
 	}
 }
 
+// Validators option sets the registry used to look up the validators named
+// in the "validate" struct tag in `Unpack`. By default the global registry
+// used by RegisterValidator is used.
+func Validators(registry *ValidatorRegistry) Option {
+	return func(o *options) {
+		o.validators = registry
+	}
+}
+
+// DeprecatedTag option sets the struct tag name used to mark struct fields
+// as deprecated in `Unpack`.
+// The default struct tag in `deprecated`.
+func DeprecatedTag(tag string) Option {
+	return func(o *options) {
+		o.deprecatedTag = tag
+	}
+}
+
+// OnDeprecated option sets a callback reporting the use of deprecated
+// settings in `Unpack`. The callback is called for each setting of a struct
+// field marked by the `deprecated` struct tag, and for each setting using an
+// alias name configured via the `alias` struct tag option.
+func OnDeprecated(fn func(Deprecation)) Option {
+	return func(o *options) {
+		o.onDeprecated = fn
+	}
+}
+
 // NoValidate disables validation when unpacking.
 func NoValidate() Option {
 	return func(o *options) {
@@ -111,6 +174,24 @@ func NoValidate() Option {
 
 // PathSep sets the path separator used to split up names into a tree like hierarchy.
 // If PathSep is not set, field names will not be split.
+//
+// Path elements holding an integer access array elements. Negative indices
+// count from the end of the array (e.g. 'hosts.-1' for the last host).
+// Elements of the form 'from:to' access the slice of elements in the range
+// [from, to), with from and to being optional (e.g. 'list.1:3'). Setting a
+// slice replaces the elements in the range with the elements of the new value.
+// When setting a value, the element '+' or the suffix '[]' appends a new
+// element to an array (e.g. 'hosts.+' or 'hosts[]').
+//
+// Array indices, slices and appends can also be given in brackets (e.g.
+// 'list[3]', 'list[1:3]'). Names containing the separator are quoted in
+// brackets (e.g. 'labels["app.kubernetes.io/name"]'), with '\' escaping
+// quotes and backslashes. Paths in error messages are formatted the same way.
+//
+// The syntax applies to the paths passed to the getters and setters, field
+// options and variable references only. Keys of documents (e.g. Go maps,
+// YAML or JSON) and struct field names are split at the separator into names
+// and array indices, but are otherwise kept verbatim.
 func PathSep(sep string) Option {
 	return func(o *options) {
 		o.pathSep = sep
@@ -215,6 +296,12 @@ var (
 	// merge dictionaries and prepend arrays to existing arrays while merging.
 	// Value merging can be overwritten in unpack by using struct tags.
 	PrependValues = makeOptValueHandling(cfgArrPrepend)
+
+	// MergePatch option configures all merging operations to follow the JSON
+	// Merge Patch (RFC 7386) semantics. A null value removes the target key,
+	// dictionaries are merged recursively and all other values, including
+	// arrays, replace the target value.
+	MergePatch = makeOptValueHandling(cfgMergePatch)
 )
 
 func makeOptValueHandling(h configHandling) Option {
@@ -247,33 +334,207 @@ var (
 	// specified field. This overrides the any struct tags during unpack for the field.
 	// Nested field names can be defined using dot notation.
 	FieldPrependValues = makeFieldOptValueHandling(cfgArrPrepend)
+
+	// FieldMergePatch option configures all merging and unpacking operations to
+	// follow the JSON Merge Patch (RFC 7386) semantics for the specified field.
+	// A null value removes the target key and arrays are replaced. This
+	// overrides the any struct tags during unpack for the field. Nested field
+	// names can be defined using dot notation.
+	FieldMergePatch = makeFieldOptValueHandling(cfgMergePatch)
+)
+
+type pathHandling struct {
+	pattern  PathPattern
+	handling configHandling
+}
+
+var (
+	// PathMergeValues option configures all merging operations to use the
+	// default merging behavior for all settings matching any of the patterns.
+	PathMergeValues = makePathOptValueHandling(cfgMergeValues)
+
+	// PathReplaceValues option configures all merging operations to replace old
+	// dictionaries and arrays for all settings matching any of the patterns.
+	PathReplaceValues = makePathOptValueHandling(cfgReplaceValue)
+
+	// PathAppendValues option configures all merging operations to merge
+	// dictionaries and append arrays to existing arrays for all settings
+	// matching any of the patterns.
+	PathAppendValues = makePathOptValueHandling(cfgArrAppend)
+
+	// PathPrependValues option configures all merging operations to merge
+	// dictionaries and prepend arrays to existing arrays for all settings
+	// matching any of the patterns.
+	PathPrependValues = makePathOptValueHandling(cfgArrPrepend)
+
+	// PathMergePatch option configures all merging operations to follow the
+	// JSON Merge Patch (RFC 7386) semantics for all settings matching any of
+	// the patterns.
+	PathMergePatch = makePathOptValueHandling(cfgMergePatch)
 )
 
+// makePathOptValueHandling creates options selecting the merge handling by
+// path patterns. The handling applies to all settings nested under a matching
+// setting as well. Path patterns take precedence over the Field* options. If
+// multiple patterns match, the pattern configured last wins.
+func makePathOptValueHandling(h configHandling) func(...PathPattern) Option {
+	return func(patterns ...PathPattern) Option {
+		return func(o *options) {
+			handling := make([]pathHandling, len(o.pathHandling), len(o.pathHandling)+len(patterns))
+			copy(handling, o.pathHandling)
+			for _, p := range patterns {
+				handling = append(handling, pathHandling{pattern: p, handling: h})
+			}
+			o.pathHandling = handling
+		}
+	}
+}
+
+// fieldMergeKey is the key in the fieldHandlingTree storing the name of the
+// field used to identify array elements for the FieldMergeByKey option.
+const fieldMergeKey = "*mergekey"
+
+// FieldMergeByKey option configures all merging operations to merge the array
+// elements of the specified field by the value of the key field. Elements with
+// matching keys are merged, all other elements are appended to the array.
+// The order of the existing array elements is kept. Nested field names can be
+// defined using dot notation.
+//
+// Example merging modules by their name:
+//
+//	cfg.Merge(overrides, PathSep("."), FieldMergeByKey("modules", "module"))
+func FieldMergeByKey(fieldName, key string) Option {
+	name := strings.TrimSuffix(fieldName, ".*")
+	return func(o *options) {
+		if o.fieldHandlingTree == nil {
+			o.fieldHandlingTree = newFieldHandlingTree()
+		}
+		o.fieldHandlingTree.set(map[string]interface{}{
+			name + ".*":                uint64(cfgMergeByKey),
+			name + "." + fieldMergeKey: key,
+		}, PathSep(o.pathSep))
+	}
+}
+
 func makeFieldOptValueHandling(h configHandling) func(...string) Option {
 	return func(fieldName ...string) Option {
 		if len(fieldName) == 0 {
 			return func(_ *options) {}
 		}
 
-		table := make(map[string]configHandling)
+		table := make(map[string]interface{})
 		for _, name := range fieldName {
 			// field value config options are rendered into a Config; the '*' represents the handling method
 			// for everything nested under this field.
 			if !strings.HasSuffix(name, ".*") {
 				name = fmt.Sprintf("%s.*", name)
 			}
-			table[name] = h
+			table[name] = uint64(h)
 		}
 
 		return func(o *options) {
 			if o.fieldHandlingTree == nil {
 				o.fieldHandlingTree = newFieldHandlingTree()
 			}
-			o.fieldHandlingTree.merge(table, PathSep(o.pathSep))
+			o.fieldHandlingTree.set(table, PathSep(o.pathSep))
+		}
+	}
+}
+
+// Converter option registers a conversion function used by Unpack for values
+// of type T. The function receives the config value as bool, int64, uint64,
+// float64, string, []interface{}, map[string]interface{} or nil, and the path
+// of the setting being unpacked.
+// Converters take precedence over all other unpacking rules, including the
+// Unpacker interfaces. This allows unpacking into third-party types that do
+// not implement any of the Unpacker interfaces. Registering another converter
+// for the same type replaces the former converter.
+//
+// Example:
+//
+//	ucfg.Converter(func(v interface{}, path string) (tls.Version, error) {
+//		...
+//	})
+func Converter[T any](fn func(v interface{}, path string) (T, error)) Option {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	conv := func(v interface{}, path string) (reflect.Value, error) {
+		out := reflect.New(t).Elem()
+		res, err := fn(v, path)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if rv := reflect.ValueOf(res); rv.IsValid() {
+			out.Set(rv)
 		}
+		return out, nil
+	}
+
+	return func(o *options) {
+		converters := make(map[reflect.Type]converterFunc, len(o.converters)+1)
+		for k, v := range o.converters {
+			converters[k] = v
+		}
+		converters[t] = conv
+		o.converters = converters
+	}
+}
+
+// DetectConflicts option configures Merge to fail with ErrConflict if a
+// setting is already set to a different value or type, instead of overwriting
+// the old value. Objects are still merged recursively. Objects and arrays
+// replaced via ReplaceValues or ReplaceArrValues are compared as a whole.
+// Settings merged with MergePatch are never checked for conflicts. Use
+// AllowOverrides to permit overwriting selected settings.
+var DetectConflicts Option = doDetectConflicts
+
+func doDetectConflicts(o *options) { o.detectConflicts = true }
+
+// AllowOverrides option configures the paths of settings that can be
+// overwritten by Merge if DetectConflicts is set. Paths are separated by '.'
+// and permit overwriting all settings nested under the path. Paths are
+// compiled into path patterns (see PathPattern), such that the wildcard '*'
+// matches any field name or array index, and names containing '.' can be
+// quoted (e.g. 'labels["app.kubernetes.io/name"]'). Invalid paths are ignored.
+func AllowOverrides(paths ...string) Option {
+	patterns := make([]PathPattern, 0, len(paths))
+	for _, path := range paths {
+		if p, err := CompilePathPattern(path + ".**"); err == nil {
+			patterns = append(patterns, p)
+		}
+	}
+	return AllowOverridePatterns(patterns...)
+}
+
+// AllowOverridePatterns option configures the path patterns of settings that
+// can be overwritten by Merge if DetectConflicts is set. Unlike
+// AllowOverrides, a pattern only matches the setting itself. Use patterns like
+// 'output.**' to permit overwriting all settings nested under a path.
+func AllowOverridePatterns(patterns ...PathPattern) Option {
+	return func(o *options) {
+		o.allowOverridePatterns = append(o.allowOverridePatterns[:len(o.allowOverridePatterns):len(o.allowOverridePatterns)], patterns...)
 	}
 }
 
+// ResolveDynamic option configures Equal, Hash and Walk to resolve variable
+// expansions and use the resolved values instead of the expressions.
+var ResolveDynamic Option = doResolveDynamic
+
+func doResolveDynamic(o *options) { o.resolveDynamic = true }
+
+// MarshalText option configures Merge, NewFrom and Set to encode values of all
+// types implementing encoding.TextMarshaler as strings. Without the option,
+// only the special types supported by Unpack (e.g. net.IP, netip.Addr or
+// time.Time) are encoded as strings.
+var MarshalText Option = doMarshalText
+
+func doMarshalText(o *options) { o.marshalText = true }
+
+// PostOrder option configures Walk to visit the children of a dictionary or
+// array before the dictionary or array itself.
+var PostOrder Option = doPostOrder
+
+func doPostOrder(o *options) { o.postOrder = true }
+
 // VarExp option enables support for variable expansion. Resolve and Env options will only be effective if  VarExp is set.
 var VarExp Option = doVarExp
 
@@ -281,12 +542,13 @@ func doVarExp(o *options) { o.varexp = true }
 
 func makeOptions(opts []Option) *options {
 	o := options{
-		tag:          "config",
-		validatorTag: "validate",
-		pathSep:      "", // no separator by default
-		parsed:       map[string]spliceValue{},
-		activeFields: newFieldSet(nil),
-		maxIdx:       defaultMaxIdx,
+		tag:           "config",
+		validatorTag:  "validate",
+		deprecatedTag: "deprecated",
+		pathSep:       "", // no separator by default
+		parsed:        map[string]spliceValue{},
+		activeFields:  newFieldSet(nil),
+		maxIdx:        defaultMaxIdx,
 	}
 	for _, opt := range opts {
 		opt(&o)
@@ -323,6 +585,29 @@ func (t *fieldHandlingTree) merge(other interface{}, opts ...Option) error {
 	return cfg.Merge(other, opts...)
 }
 
+// set merges the settings in table by path. The keys of table are parsed
+// like the paths of the setters, such that field names can be quoted.
+func (t *fieldHandlingTree) set(table map[string]interface{}, opts ...Option) error {
+	cfg := (*Config)(t)
+
+	paths := make([]string, 0, len(table))
+	for path := range table {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		tmp := New()
+		if err := Set(tmp, path, table[path], opts...); err != nil {
+			return err
+		}
+		if err := cfg.Merge(tmp, opts...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (t *fieldHandlingTree) child(fieldName string, idx int) (*fieldHandlingTree, error) {
 	cfg := (*Config)(t)
 	child, err := cfg.Child(fieldName, idx)
@@ -341,6 +626,12 @@ func (t *fieldHandlingTree) configHandling(fieldName string, idx int) (configHan
 	return configHandling(handling), nil
 }
 
+func (t *fieldHandlingTree) mergeKey() string {
+	cfg := (*Config)(t)
+	key, _ := cfg.String(fieldMergeKey, -1)
+	return key
+}
+
 func (t *fieldHandlingTree) wildcard() (*fieldHandlingTree, error) {
 	return t.child("**", -1)
 }
@@ -19,11 +19,54 @@ package ucfg
 
 import (
 	"fmt"
+	"math"
 	"regexp"
 	"strconv"
 	"strings"
 )
 
+// Path is a parsed setting path. Paths can be used to build and inspect
+// setting paths, without having to know the separator and escaping rules of
+// the path syntax. The zero value is the empty path, addressing the
+// configuration itself.
+type Path struct {
+	fields []field
+}
+
+// SegmentKind describes the type of a PathSegment.
+type SegmentKind uint8
+
+const (
+	// SegmentName addresses a field of a dictionary by name.
+	SegmentName SegmentKind = iota
+
+	// SegmentIndex addresses a single array element.
+	SegmentIndex
+
+	// SegmentSlice addresses a range of array elements.
+	SegmentSlice
+
+	// SegmentAppend addresses a new element appended to an array.
+	SegmentAppend
+)
+
+// PathSegment is a single element of a Path.
+type PathSegment struct {
+	Kind SegmentKind
+
+	// Name is the field name of a SegmentName.
+	Name string
+
+	// Index is the array index of a SegmentIndex. Negative indices count from
+	// the end of the array.
+	Index int
+
+	// From and To are the bounds of a SegmentSlice. The bounds are only set if
+	// HasFrom and HasTo are true.
+	From, To       int
+	HasFrom, HasTo bool
+}
+
 type cfgPath struct {
 	fields []field
 	sep    string
@@ -44,6 +87,92 @@ type idxField struct {
 	i int
 }
 
+// appendField adds a new element to the end of an array when setting a value.
+type appendField struct{}
+
+// sliceField selects the array elements in the range [from, to). Negative
+// bounds count from the end of the array.
+type sliceField struct {
+	from, to       int
+	hasFrom, hasTo bool
+}
+
+// ParsePath parses a setting path using the separator sep, following the
+// same rules as the getters and setters with the PathSep option. If sep is
+// empty, the path is not split.
+func ParsePath(path, sep string) Path {
+	if path == "" {
+		return Path{}
+	}
+	return Path{fields: parsePath(path, sep, defaultMaxIdx, false, false).fields}
+}
+
+// ParsePathWithOptions parses a setting path like the getters and setters,
+// using the separator configured by the PathSep option.
+func ParsePathWithOptions(path string, opts ...Option) Path {
+	if path == "" {
+		return Path{}
+	}
+	return Path{fields: parsePathWithOpts(path, makeOptions(opts)).fields}
+}
+
+// Append returns a new path addressing the field name within p. The name is
+// used as is, without being split or parsed.
+func (p Path) Append(name string) Path {
+	return p.with(namedField{name})
+}
+
+// Index returns a new path addressing the array element i within p. Negative
+// indices count from the end of the array.
+func (p Path) Index(i int) Path {
+	return p.with(idxField{i})
+}
+
+func (p Path) with(f field) Path {
+	fields := make([]field, len(p.fields), len(p.fields)+1)
+	copy(fields, p.fields)
+	return Path{fields: append(fields, f)}
+}
+
+// String formats the path using the separator sep, or '.' if sep is empty.
+// Names containing the separator or brackets, and names looking like array
+// indices, slices or appends (e.g. "0" or "+"), are quoted, such that the
+// result can be parsed again by ParsePath.
+func (p Path) String(sep string) string {
+	if sep == "" {
+		sep = "."
+	}
+	return p.cfgPath(sep).String()
+}
+
+// Segments returns the elements of p.
+func (p Path) Segments() []PathSegment {
+	segments := make([]PathSegment, len(p.fields))
+	for i, f := range p.fields {
+		switch f := f.(type) {
+		case namedField:
+			segments[i] = PathSegment{Kind: SegmentName, Name: f.name}
+		case idxField:
+			segments[i] = PathSegment{Kind: SegmentIndex, Index: f.i}
+		case sliceField:
+			segments[i] = PathSegment{
+				Kind:    SegmentSlice,
+				From:    f.from,
+				To:      f.to,
+				HasFrom: f.hasFrom,
+				HasTo:   f.hasTo,
+			}
+		case appendField:
+			segments[i] = PathSegment{Kind: SegmentAppend}
+		}
+	}
+	return segments
+}
+
+func (p Path) cfgPath(sep string) cfgPath {
+	return cfgPath{fields: p.fields, sep: sep}
+}
+
 func parsePathIdx(in string, idx int, opts *options) cfgPath {
 	if in == "" {
 		return cfgPath{
@@ -67,14 +196,23 @@ const (
 var escapePathReg = regexp.MustCompile(escapePathRegExp)
 
 func parsePath(in, sep string, maxIdx int64, enableNumKeys, allowEscapePath bool) cfgPath {
-	if sep == "" || (allowEscapePath && escapePathReg.MatchString(in)) {
+	if allowEscapePath && escapePathReg.MatchString(in) {
+		return cfgPath{
+			sep:    sep,
+			fields: []field{parseField(in, maxIdx, enableNumKeys)},
+		}
+	}
+	if sep == "" {
 		return cfgPath{
 			sep:    sep,
 			fields: []field{parseField(in, maxIdx, enableNumKeys)},
 		}
 	}
 
-	elems := strings.Split(in, sep)
+	elems, ok := splitPath(in, sep)
+	if !ok {
+		elems = strings.Split(in, sep)
+	}
 	fields := make([]field, 0, len(elems))
 	// If property is the name with separators, for example "inputs.0.i"
 	// fall back to original implementation
@@ -82,49 +220,273 @@ func parsePath(in, sep string, maxIdx int64, enableNumKeys, allowEscapePath bool
 		enableNumKeys = false
 	}
 	for _, elem := range elems {
-		fields = append(fields, parseField(elem, maxIdx, enableNumKeys))
+		fields = parseElem(fields, elem, maxIdx, enableNumKeys)
 	}
 	return cfgPath{fields: fields, sep: sep}
 }
 
+// splitPath splits the path at sep, ignoring separators within brackets and
+// quoted names. Returns false if a bracket or quote is not terminated.
+func splitPath(in, sep string) ([]string, bool) {
+	var elems []string
+	var quote byte
+	inBracket := false
+	start := 0
+	for i := 0; i < len(in); i++ {
+		switch c := in[i]; {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case inBracket:
+			if c == '"' || c == '\'' {
+				quote = c
+			} else if c == ']' {
+				inBracket = false
+			}
+		case c == '[':
+			inBracket = true
+		case strings.HasPrefix(in[i:], sep):
+			elems = append(elems, in[start:i])
+			start = i + len(sep)
+			i = start - 1
+		}
+	}
+	if quote != 0 || inBracket {
+		return nil, false
+	}
+	return append(elems, in[start:]), true
+}
+
+// parseElem parses a path element. An element is a name, optionally followed
+// by bracketed array indices, slices, appends or quoted names (e.g.
+// 'hosts[0]', 'hosts[]' or 'labels["app.kubernetes.io/name"]'). Elements
+// with invalid brackets are parsed as a single field.
+func parseElem(fields []field, elem string, maxIdx int64, enableNumKeys bool) []field {
+	i := strings.IndexByte(elem, '[')
+	if i < 0 {
+		return append(fields, parseField(elem, maxIdx, enableNumKeys))
+	}
+
+	var parsed []field
+	if i > 0 {
+		parsed = append(parsed, parseField(elem[:i], maxIdx, enableNumKeys))
+	}
+	for rest := elem[i:]; rest != ""; {
+		f, n, ok := parseBracket(rest, maxIdx)
+		if !ok {
+			return append(fields, parseField(elem, maxIdx, enableNumKeys))
+		}
+		parsed = append(parsed, f)
+		rest = rest[n:]
+	}
+	return append(fields, parsed...)
+}
+
+// parseBracket parses the bracket expression at the beginning of in, returning
+// the field and the number of bytes consumed.
+func parseBracket(in string, maxIdx int64) (field, int, bool) {
+	if len(in) < 2 || in[0] != '[' {
+		return nil, 0, false
+	}
+
+	if q := in[1]; q == '"' || q == '\'' {
+		var name strings.Builder
+		for i := 2; i < len(in); i++ {
+			switch c := in[i]; {
+			case c == '\\' && i+1 < len(in):
+				i++
+				name.WriteByte(in[i])
+			case c == q:
+				if i+1 < len(in) && in[i+1] == ']' {
+					return namedField{name.String()}, i + 2, true
+				}
+				return nil, 0, false
+			default:
+				name.WriteByte(c)
+			}
+		}
+		return nil, 0, false
+	}
+
+	end := strings.IndexByte(in, ']')
+	if end < 0 {
+		return nil, 0, false
+	}
+
+	content := in[1:end]
+	if content == "" {
+		return appendField{}, end + 1, true
+	}
+	if idx, ok := parseIdx(content, maxIdx); ok {
+		return idxField{idx}, end + 1, true
+	}
+	if f, ok := parseSlice(content, maxIdx); ok {
+		return f, end + 1, true
+	}
+	return nil, 0, false
+}
+
+// joinPath appends the field name to path using sep. Names containing the
+// separator or brackets, or names that would be parsed as array indices,
+// slices or appends, are quoted, such that the path can be parsed again.
+func joinPath(path, name, sep string) string {
+	if sep != "" && needsQuote(name, sep) {
+		return path + "[" + quoteName(name) + "]"
+	}
+	return joinElem(path, name, sep)
+}
+
+func joinElem(path, elem, sep string) string {
+	if path == "" {
+		return elem
+	}
+	return path + sep + elem
+}
+
+func needsQuote(name, sep string) bool {
+	if strings.Contains(name, sep) || strings.ContainsAny(name, "[]") || name == "+" {
+		return true
+	}
+	if _, ok := parseIdx(name, math.MaxInt64); ok {
+		return true
+	}
+	_, ok := parseSlice(name, math.MaxInt64)
+	return ok
+}
+
+func quoteName(name string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(name); i++ {
+		if c := name[i]; c == '"' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(name[i])
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
 func parsePathWithOpts(in string, opts *options) cfgPath {
 	return parsePath(in, opts.pathSep, opts.maxIdx, opts.enableNumKeys, opts.escapePath)
 }
 
+// parseKeyPath splits the key of a setting read from a document (e.g. a Go
+// map, YAML or JSON) or a struct field name at sep. Unlike parsePath, keys
+// are only split into names and array indices. Brackets, quotes, slices and
+// appends are kept verbatim.
+func parseKeyPath(in string, opts *options) cfgPath {
+	sep := opts.pathSep
+	if sep == "" || (opts.escapePath && escapePathReg.MatchString(in)) {
+		return cfgPath{
+			sep:    sep,
+			fields: []field{parseKeyField(in, opts.maxIdx, opts.enableNumKeys)},
+		}
+	}
+
+	elems := strings.Split(in, sep)
+	fields := make([]field, 0, len(elems))
+	// If property is the name with separators, for example "inputs.0.i"
+	// fall back to original implementation
+	enableNumKeys := opts.enableNumKeys && len(elems) == 1
+	for _, elem := range elems {
+		fields = append(fields, parseKeyField(elem, opts.maxIdx, enableNumKeys))
+	}
+	return cfgPath{fields: fields, sep: sep}
+}
+
+func parseKeyField(in string, maxIdx int64, enableNumKeys bool) field {
+	if !enableNumKeys {
+		if idx, ok := parseIdx(in, maxIdx); ok {
+			return idxField{idx}
+		}
+	}
+	return namedField{in}
+}
+
 func parseField(in string, maxIdx int64, enableNumKeys bool) field {
 	// If numeric keys are not enabled, fallback to the original implementation
 	if !enableNumKeys {
-		idx, err := strconv.ParseInt(in, 0, 64)
-		// Limit index value to the configurable max.
-		// If the idx > opts.maxIdx treat it as a regular named field.
-		// This preserves the current behavour for small index fields values (<= opts.maxIdx)
-		// and prevents large memory allocations or OOM if the string is large numeric value
-		if err == nil && idx <= int64(maxIdx) {
-			return idxField{int(idx)}
+		if in == "+" || in == "[]" {
+			return appendField{}
+		}
+		if idx, ok := parseIdx(in, maxIdx); ok {
+			return idxField{idx}
+		}
+		if f, ok := parseSlice(in, maxIdx); ok {
+			return f
 		}
 	}
 	return namedField{in}
 }
 
+func parseIdx(in string, maxIdx int64) (int, bool) {
+	idx, err := strconv.ParseInt(in, 0, 64)
+	// Limit index value to the configurable max.
+	// If the |idx| > opts.maxIdx treat it as a regular named field.
+	// This preserves the current behavour for small index fields values (<= opts.maxIdx)
+	// and prevents large memory allocations or OOM if the string is large numeric value
+	if err != nil || idx > maxIdx || idx < -maxIdx {
+		return 0, false
+	}
+	return int(idx), true
+}
+
+// parseSlice parses slices of the form 'from:to', with from and to being
+// optional.
+func parseSlice(in string, maxIdx int64) (sliceField, bool) {
+	i := strings.IndexByte(in, ':')
+	if i < 0 || in == ":" {
+		return sliceField{}, false
+	}
+
+	var f sliceField
+	var ok bool
+	if from := in[:i]; from != "" {
+		if f.from, ok = parseIdx(from, maxIdx); !ok {
+			return sliceField{}, false
+		}
+		f.hasFrom = true
+	}
+	if to := in[i+1:]; to != "" {
+		if f.to, ok = parseIdx(to, maxIdx); !ok {
+			return sliceField{}, false
+		}
+		f.hasTo = true
+	}
+	return f, true
+}
+
 func (p cfgPath) String() string {
 	if len(p.fields) == 0 {
 		return ""
 	}
 
 	if len(p.fields) == 1 {
-		return p.fields[0].String()
-	}
-
-	s := make([]string, 0, len(p.fields))
-	for _, f := range p.fields {
-		s = append(s, f.String())
+		return joinField("", p.fields[0], p.sep)
 	}
 
 	sep := p.sep
 	if sep == "" {
 		sep = "."
 	}
-	return strings.Join(s, sep)
+
+	var path string
+	for _, f := range p.fields {
+		path = joinField(path, f, sep)
+	}
+	return path
+}
+
+// joinField appends the formatted field to path. Only names are quoted.
+func joinField(path string, f field, sep string) string {
+	if n, ok := f.(namedField); ok {
+		return joinPath(path, n.name, sep)
+	}
+	return joinElem(path, f.String(), sep)
 }
 
 func (n namedField) String() string {
@@ -135,6 +497,58 @@ func (i idxField) String() string {
 	return fmt.Sprintf("%d", i.i)
 }
 
+func (appendField) String() string {
+	return "+"
+}
+
+func (s sliceField) String() string {
+	var from, to string
+	if s.hasFrom {
+		from = strconv.Itoa(s.from)
+	}
+	if s.hasTo {
+		to = strconv.Itoa(s.to)
+	}
+	return from + ":" + to
+}
+
+// index resolves negative indices relative to the array length n.
+func (i idxField) index(n int) int {
+	if i.i < 0 {
+		return n + i.i
+	}
+	return i.i
+}
+
+// bounds resolves the slice bounds for an array of length n. Bounds outside
+// of the array are clamped to the array.
+func (s sliceField) bounds(n int) (int, int) {
+	clamp := func(i int) int {
+		if i < 0 {
+			i += n
+		}
+		if i < 0 {
+			return 0
+		}
+		if i > n {
+			return n
+		}
+		return i
+	}
+
+	from, to := 0, n
+	if s.hasFrom {
+		from = clamp(s.from)
+	}
+	if s.hasTo {
+		to = clamp(s.to)
+	}
+	if to < from {
+		to = from
+	}
+	return from, to
+}
+
 func (p cfgPath) Has(cfg *Config, opt *options) (bool, Error) {
 	fields := p.fields
 
@@ -161,6 +575,11 @@ func (p cfgPath) Has(cfg *Config, opt *options) (bool, Error) {
 	return true, nil
 }
 
+// fieldPath returns a path holding the single field f.
+func (p cfgPath) fieldPath(f field) cfgPath {
+	return cfgPath{fields: []field{f}, sep: p.sep}
+}
+
 func (p cfgPath) GetValue(cfg *Config, opt *options) (value, Error) {
 	fields := p.fields
 
@@ -173,7 +592,7 @@ func (p cfgPath) GetValue(cfg *Config, opt *options) (value, Error) {
 		}
 
 		if next == nil {
-			return nil, raiseMissing(cfg, field.String())
+			return nil, raiseMissingPath(cfg, p.fieldPath(field))
 		}
 
 		cur = next
@@ -182,7 +601,7 @@ func (p cfgPath) GetValue(cfg *Config, opt *options) (value, Error) {
 	field := fields[0]
 	v, err := field.GetValue(opt, cur)
 	if err != nil {
-		return nil, raiseMissing(cfg, field.String())
+		return nil, raiseMissingPath(cfg, p.fieldPath(field))
 	}
 	return v, nil
 }
@@ -208,10 +627,34 @@ func (i idxField) GetValue(opts *options, elem value) (value, Error) {
 	}
 
 	arr := cfg.fields.array()
-	if i.i >= len(arr) {
-		return nil, raiseMissing(cfg, i.String())
+	idx := i.index(len(arr))
+	if idx < 0 || idx >= len(arr) {
+		return nil, raiseMissingPath(cfg, cfgPath{fields: []field{i}})
 	}
-	return arr[i.i], nil
+	return arr[idx], nil
+}
+
+func (appendField) GetValue(opts *options, elem value) (value, Error) {
+	// the element to be appended does not exist yet
+	return nil, nil
+}
+
+// GetValue returns a new array holding the selected elements. The elements
+// are not copied and keep their position in the original array.
+func (s sliceField) GetValue(opts *options, elem value) (value, Error) {
+	cfg, err := elem.toConfig(opts)
+	if err != nil {
+		return nil, raiseExpectedObject(opts, elem)
+	}
+
+	arr := cfg.fields.array()
+	from, to := s.bounds(len(arr))
+
+	sub := New()
+	sub.ctx = context{parent: elem, field: s.String()}
+	sub.metadata = cfg.metadata
+	sub.fields.a = append([]value(nil), arr[from:to]...)
+	return cfgSub{sub}, nil
 }
 
 func (p cfgPath) SetValue(cfg *Config, opt *options, val value) Error {
@@ -232,6 +675,10 @@ func (p cfgPath) SetValue(cfg *Config, opt *options, val value) Error {
 		if isNil(v) {
 			break
 		}
+		if _, isSlice := field.(sliceField); isSlice {
+			// slices are copies and can not be modified
+			return raiseExpectedObject(opt, v)
+		}
 		node = v
 	}
 
@@ -270,8 +717,43 @@ func (i idxField) SetValue(opts *options, elem value, v value) Error {
 		return raiseExpectedObject(opts, elem)
 	}
 
-	sub.c.fields.setAt(i.i, elem, v)
-	v.SetContext(context{parent: elem, field: i.String()})
+	idx := i.index(len(sub.c.fields.array()))
+	if idx < 0 {
+		return raiseIndexOutOfBounds(opts, elem, i.i)
+	}
+
+	sub.c.fields.setAt(idx, elem, v)
+	v.SetContext(context{parent: elem, field: fmt.Sprintf("%d", idx)})
+	return nil
+}
+
+func (appendField) SetValue(opts *options, elem value, v value) Error {
+	sub, ok := elem.(cfgSub)
+	if !ok {
+		return raiseExpectedObject(opts, elem)
+	}
+
+	idx := len(sub.c.fields.array())
+	sub.c.fields.setAt(idx, elem, v)
+	v.SetContext(context{parent: elem, field: fmt.Sprintf("%d", idx)})
+	return nil
+}
+
+// SetValue replaces the selected elements with the elements of v if v is an
+// array, or with v otherwise.
+func (s sliceField) SetValue(opts *options, elem value, v value) Error {
+	sub, ok := elem.(cfgSub)
+	if !ok {
+		return raiseExpectedObject(opts, elem)
+	}
+
+	vals := []value{v}
+	if arr, ok := v.(cfgSub); ok && configKind(arr.c) == KindArray {
+		vals = arr.c.fields.array()
+	}
+
+	from, to := s.bounds(len(sub.c.fields.array()))
+	sub.c.fields.splice(from, to, elem, vals)
 	return nil
 }
 
@@ -297,6 +779,10 @@ func (p cfgPath) Remove(cfg *Config, opt *options) (bool, error) {
 		if next == nil {
 			return false, err
 		}
+		if _, isSlice := field.(sliceField); isSlice {
+			// slices are copies and can not be modified
+			return false, raiseExpectedObject(opt, next)
+		}
 
 		cur = next
 	}
@@ -328,6 +814,32 @@ func (i idxField) Remove(opts *options, elem value) (bool, Error) {
 		return false, raiseExpectedObject(opts, elem)
 	}
 
-	removed := sub.c.fields.delAt(i.i)
-	return removed, nil
+	arr := sub.c.fields.array()
+	idx := i.index(len(arr))
+	if idx < 0 || idx >= len(arr) {
+		return false, nil
+	}
+	sub.c.fields.splice(idx, idx+1, elem, nil)
+	return true, nil
+}
+
+func (appendField) Remove(opts *options, elem value) (bool, Error) {
+	if _, ok := elem.(cfgSub); !ok {
+		return false, raiseExpectedObject(opts, elem)
+	}
+	return false, nil
+}
+
+func (s sliceField) Remove(opts *options, elem value) (bool, Error) {
+	sub, ok := elem.(cfgSub)
+	if !ok {
+		return false, raiseExpectedObject(opts, elem)
+	}
+
+	from, to := s.bounds(len(sub.c.fields.array()))
+	if from == to {
+		return false, nil
+	}
+	sub.c.fields.splice(from, to, elem, nil)
+	return true, nil
 }
@@ -20,6 +20,8 @@ package ucfg
 import (
 	"reflect"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func Test_parsePathWithOpts(t *testing.T) {
@@ -58,6 +60,109 @@ func Test_parsePathWithOpts(t *testing.T) {
 				sep: ".",
 			},
 		},
+		{
+			name: "negative index",
+			args: args{
+				in:   "hosts.-1",
+				opts: &options{pathSep: ".", maxIdx: defaultMaxIdx},
+			},
+			want: cfgPath{
+				fields: []field{namedField{"hosts"}, idxField{-1}},
+				sep:    ".",
+			},
+		},
+		{
+			name: "slice",
+			args: args{
+				in:   "list.1:3.-2:",
+				opts: &options{pathSep: ".", maxIdx: defaultMaxIdx},
+			},
+			want: cfgPath{
+				fields: []field{
+					namedField{"list"},
+					sliceField{from: 1, to: 3, hasFrom: true, hasTo: true},
+					sliceField{from: -2, hasFrom: true},
+				},
+				sep: ".",
+			},
+		},
+		{
+			name: "no slice",
+			args: args{
+				in:   "a.10:x.:",
+				opts: &options{pathSep: ".", maxIdx: defaultMaxIdx},
+			},
+			want: cfgPath{
+				fields: []field{namedField{"a"}, namedField{"10:x"}, namedField{":"}},
+				sep:    ".",
+			},
+		},
+		{
+			name: "append",
+			args: args{
+				in:   "hosts.+.ports[]",
+				opts: &options{pathSep: ".", maxIdx: defaultMaxIdx},
+			},
+			want: cfgPath{
+				fields: []field{
+					namedField{"hosts"},
+					appendField{},
+					namedField{"ports"},
+					appendField{},
+				},
+				sep: ".",
+			},
+		},
+		{
+			name: "brackets",
+			args: args{
+				in:   `labels["app.kubernetes.io/name"].list[3][-1:]['it\'s']`,
+				opts: &options{pathSep: ".", maxIdx: defaultMaxIdx},
+			},
+			want: cfgPath{
+				fields: []field{
+					namedField{"labels"},
+					namedField{"app.kubernetes.io/name"},
+					namedField{"list"},
+					idxField{3},
+					sliceField{from: -1, hasFrom: true},
+					namedField{"it's"},
+				},
+				sep: ".",
+			},
+		},
+		{
+			name: "invalid brackets",
+			args: args{
+				in:   "a[b].c[1",
+				opts: &options{pathSep: ".", maxIdx: defaultMaxIdx},
+			},
+			want: cfgPath{
+				fields: []field{namedField{"a[b]"}, namedField{"c[1"}},
+				sep:    ".",
+			},
+		},
+		{
+			name: "unterminated quote",
+			args: args{
+				in:   `a["b.c`,
+				opts: &options{pathSep: ".", maxIdx: defaultMaxIdx},
+			},
+			want: cfgPath{
+				fields: []field{namedField{`a["b`}, namedField{"c"}},
+				sep:    ".",
+			},
+		},
+		{
+			name: "no brackets without separator",
+			args: args{
+				in:   "a[0]",
+				opts: &options{maxIdx: defaultMaxIdx},
+			},
+			want: cfgPath{
+				fields: []field{namedField{"a[0]"}},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -67,3 +172,117 @@ func Test_parsePathWithOpts(t *testing.T) {
 		})
 	}
 }
+
+func TestPathString(t *testing.T) {
+	cases := map[string]string{
+		"a.b.0":                       "a.b.0",
+		`a["b.c"].d`:                  `a["b.c"].d`,
+		`labels['app.kubernetes.io']`: `labels["app.kubernetes.io"]`,
+		`a["say \"hi\"."]`:            `a["say \"hi\"."]`,
+		"list[3]":                     "list.3",
+		"list[1:3]":                   "list.1:3",
+		"hosts[]":                     "hosts.+",
+		`a["0"]`:                      `a["0"]`,
+		`a["-1"].b`:                   `a["-1"].b`,
+		`a["+"]`:                      `a["+"]`,
+		`a["1:3"]`:                    `a["1:3"]`,
+	}
+
+	for in, expected := range cases {
+		p := parsePath(in, ".", defaultMaxIdx, false, false)
+		assert.Equal(t, expected, p.String(), in)
+		assert.Equal(t, p, parsePath(p.String(), ".", defaultMaxIdx, false, false), in)
+	}
+}
+
+func TestPath(t *testing.T) {
+	p := ParsePath("outputs.elasticsearch.hosts", ".")
+	assert.Equal(t, "outputs/elasticsearch/hosts", p.String("/"))
+
+	p = Path{}.Append("labels").Append("app.kubernetes.io/name")
+	assert.Equal(t, `labels["app.kubernetes.io/name"]`, p.String("."))
+	assert.Equal(t, `labels["app.kubernetes.io/name"]`, p.String("/"))
+	assert.Equal(t, "labels:app.kubernetes.io/name", p.String(":"))
+	assert.Equal(t, p, ParsePath(p.String("."), "."))
+
+	base := ParsePath("inputs", ".")
+	first, last := base.Index(0), base.Index(-1)
+	assert.Equal(t, "inputs.0", first.String(""))
+	assert.Equal(t, "inputs.-1", last.String(""))
+	assert.Equal(t, "inputs", base.String(""))
+
+	assert.Equal(t, []PathSegment{
+		{Kind: SegmentName, Name: "list"},
+		{Kind: SegmentIndex, Index: 2},
+		{Kind: SegmentSlice, From: 1, HasFrom: true},
+		{Kind: SegmentAppend},
+	}, ParsePath("list[2][1:].+", ".").Segments())
+
+	assert.Empty(t, Path{}.Segments())
+	assert.Equal(t, Path{}, ParsePath("", "."))
+}
+
+func TestQuotedPathRoundTrip(t *testing.T) {
+	newConfig := func() *Config {
+		return MustNewFrom(map[string]interface{}{
+			"labels": map[string]interface{}{
+				"app.kubernetes.io/name": "beat",
+				"team[0]":                "obs",
+			},
+		})
+	}
+
+	expected := map[string]string{
+		`labels["app.kubernetes.io/name"]`: "beat",
+		`labels["team[0]"]`:                "obs",
+	}
+
+	var walked []string
+	err := newConfig().Walk(func(path string, kind Kind, _ interface{}, _ *Meta) error {
+		if kind == KindString {
+			walked = append(walked, path)
+		}
+		return nil
+	}, PathSep("."))
+	assert.NoError(t, err)
+
+	results, err := newConfig().Query("labels.*")
+	assert.NoError(t, err)
+	var queried []string
+	for _, r := range results {
+		queried = append(queried, r.Path)
+	}
+
+	sources := map[string][]string{
+		"FlattenedKeys": newConfig().FlattenedKeys(PathSep(".")),
+		"Walk":          walked,
+		"Query":         queried,
+	}
+	for name, paths := range sources {
+		paths := paths
+		t.Run(name, func(t *testing.T) {
+			assert.Len(t, paths, len(expected))
+			for _, path := range paths {
+				want, ok := expected[path]
+				if !assert.True(t, ok, "unexpected path %v", path) {
+					continue
+				}
+
+				cfg := newConfig()
+				got, err := Get[string](cfg, path, PathSep("."))
+				assert.NoError(t, err)
+				assert.Equal(t, want, got)
+
+				assert.NoError(t, Set(cfg, path, "updated", PathSep(".")))
+				got, err = Get[string](cfg, path, PathSep("."))
+				assert.NoError(t, err)
+				assert.Equal(t, "updated", got)
+
+				removed, err := cfg.Remove(path, -1, PathSep("."))
+				assert.NoError(t, err)
+				assert.True(t, removed)
+				assert.Len(t, cfg.FlattenedKeys(PathSep(".")), len(expected)-1)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ucfg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// PathPattern matches '.' separated setting paths, like the keys returned by
+// FlattenedKeys. A pattern consists of '.' separated segments:
+//
+//	name      matches the field name. The wildcard '*' matches any sequence
+//	          of characters and '?' matches a single character within the name.
+//	*         matches any single field name or array index.
+//	**        matches any number of nested fields and array indices,
+//	          including none.
+//	name[n]   matches the array index n of the field name. Multiple indices
+//	          can be given for nested arrays (e.g. 'matrix[0][1]').
+//	name[*]   matches any array index of the field name.
+//	["name"]  matches the field name literally. Quoted names can contain
+//	          '.', brackets or wildcards (e.g. 'labels["app.kubernetes.io/name"]').
+//
+// The name can be omitted for array indices (e.g. '[0].hosts' or 'a.[*]').
+// Paths are parsed like the paths accepted by Get, such that quoted names in
+// paths only match a single segment.
+//
+// Example matching the processors array settings at any depth:
+//
+//	ucfg.MustCompilePathPattern("**.processors")
+type PathPattern struct {
+	pattern  string
+	segments []patternSegment
+}
+
+type patternSegmentKind uint8
+
+const (
+	segName patternSegmentKind = iota
+	segAnyDepth
+	segIndex
+	segAnyIndex
+)
+
+type patternSegment struct {
+	kind    patternSegmentKind
+	name    string
+	literal bool
+	idx     int
+}
+
+// CompilePathPattern parses a path pattern. Returns ErrInvalidPathPattern if
+// the pattern is not valid.
+func CompilePathPattern(pattern string) (PathPattern, error) {
+	if pattern == "" {
+		return PathPattern{}, raiseInvalidPathPattern(pattern, "empty pattern")
+	}
+
+	parts, ok := splitPath(pattern, ".")
+	if !ok {
+		return PathPattern{}, raiseInvalidPathPattern(pattern, "unterminated index")
+	}
+
+	var segments []patternSegment
+	for _, part := range parts {
+		if part == "" {
+			return PathPattern{}, raiseInvalidPathPattern(pattern, "empty segment")
+		}
+
+		name := part
+		if i := strings.IndexByte(part, '['); i >= 0 {
+			name, part = part[:i], part[i:]
+		} else {
+			part = ""
+		}
+
+		switch {
+		case name == "**":
+			if part != "" {
+				return PathPattern{}, raiseInvalidPathPattern(pattern, "'**' can not be indexed")
+			}
+			segments = append(segments, patternSegment{kind: segAnyDepth})
+		case name != "":
+			if strings.ContainsAny(name, "]") {
+				return PathPattern{}, raiseInvalidPathPattern(pattern, "unexpected ']'")
+			}
+			segments = append(segments, patternSegment{kind: segName, name: name})
+		}
+
+		for part != "" {
+			end := strings.IndexByte(part, ']')
+			if part[0] != '[' || end < 0 {
+				return PathPattern{}, raiseInvalidPathPattern(pattern, "unterminated index")
+			}
+
+			if q := part[1]; q == '"' || q == '\'' {
+				f, n, ok := parseBracket(part, defaultMaxIdx)
+				if !ok {
+					return PathPattern{}, raiseInvalidPathPattern(pattern, "invalid quoted name")
+				}
+				name := f.(namedField).name
+				segments = append(segments, patternSegment{kind: segName, name: name, literal: true})
+				part = part[n:]
+				continue
+			}
+
+			idx := part[1:end]
+			part = part[end+1:]
+			if idx == "*" {
+				segments = append(segments, patternSegment{kind: segAnyIndex})
+				continue
+			}
+
+			i, err := strconv.ParseUint(idx, 10, 31)
+			if err != nil {
+				return PathPattern{}, raiseInvalidPathPattern(pattern, fmt.Sprintf("invalid index '%v'", idx))
+			}
+			segments = append(segments, patternSegment{kind: segIndex, idx: int(i)})
+		}
+	}
+
+	return PathPattern{pattern: pattern, segments: segments}, nil
+}
+
+// MustCompilePathPattern parses a path pattern like CompilePathPattern, but
+// panics if the pattern is not valid.
+func MustCompilePathPattern(pattern string) PathPattern {
+	p, err := CompilePathPattern(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// String returns the source pattern.
+func (p PathPattern) String() string {
+	return p.pattern
+}
+
+// Match checks if the '.' separated path matches the pattern. Array indices
+// are represented by their number in path (e.g. 'hosts.0'). Names containing
+// '.' must be quoted (e.g. 'labels["app.kubernetes.io/name"]').
+func (p PathPattern) Match(path string) bool {
+	if path == "" {
+		return p.matchPath(Path{})
+	}
+	return p.matchPath(ParsePath(path, "."))
+}
+
+func (p PathPattern) matchPath(path Path) bool {
+	if len(p.segments) == 0 {
+		return false
+	}
+	return matchSegments(p.segments, path.fields)
+}
+
+func matchSegments(segments []patternSegment, elems []field) bool {
+	for len(segments) > 0 {
+		seg := segments[0]
+		if seg.kind == segAnyDepth {
+			for i := 0; i <= len(elems); i++ {
+				if matchSegments(segments[1:], elems[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(elems) == 0 || !seg.match(elems[0]) {
+			return false
+		}
+		segments, elems = segments[1:], elems[1:]
+	}
+	return len(elems) == 0
+}
+
+func (s patternSegment) match(elem field) bool {
+	switch f := elem.(type) {
+	case namedField:
+		if s.kind != segName {
+			return false
+		}
+		if s.literal {
+			return s.name == f.name
+		}
+		return matchGlob(s.name, f.name)
+
+	case idxField:
+		switch s.kind {
+		case segName:
+			return !s.literal && matchGlob(s.name, strconv.Itoa(f.i))
+		case segIndex:
+			return f.i == s.idx
+		case segAnyIndex:
+			return f.i >= 0
+		}
+	}
+	return false
+}
+
+// matchGlob matches s against a pattern supporting the wildcards '*' and '?'.
+func matchGlob(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			pattern = strings.TrimLeft(pattern, "*")
+			if pattern == "" {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if matchGlob(pattern, s[i:]) {
+					return true
+				}
+			}
+			return false
+
+		case '?':
+			if s == "" {
+				return false
+			}
+			_, size := utf8.DecodeRuneInString(s)
+			s = s[size:]
+
+		default:
+			if s == "" || s[0] != pattern[0] {
+				return false
+			}
+			s = s[1:]
+		}
+		pattern = pattern[1:]
+	}
+	return s == ""
+}
+
+func matchAnyPathPattern(patterns []PathPattern, path Path) bool {
+	for _, p := range patterns {
+		if p.matchPath(path) {
+			return true
+		}
+	}
+	return false
+}
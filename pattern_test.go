@@ -0,0 +1,104 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ucfg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathPatternMatch(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"a.b", "a.b", true},
+		{"a.b", "a.c", false},
+		{"a.b", "a.b.c", false},
+		{"a.*", "a.b", true},
+		{"a.*", "a.0", true},
+		{"a.*", "a.b.c", false},
+		{"a.*_key", "a.ssl_key", true},
+		{"a.*_key", "a.ssl_cert", false},
+		{"a.?", "a.b", true},
+		{"a.?", "a.bc", false},
+		{"a.**", "a", true},
+		{"a.**", "a.b.c", true},
+		{"**", "", true},
+		{"**.password", "password", true},
+		{"**.password", "output.es.password", true},
+		{"**.processors", "a.0.processors", true},
+		{"**.*pass*", "output.es.passwd", true},
+		{"a.**.c", "a.c", true},
+		{"a.**.c", "a.b.b.c", true},
+		{"a.**.c", "a.b.b.d", false},
+		{"hosts[0]", "hosts.0", true},
+		{"hosts[0]", "hosts.1", false},
+		{"hosts[*]", "hosts.12", true},
+		{"hosts[*]", "hosts.name", false},
+		{"hosts.0", "hosts.0", true},
+		{"m[1][*].x", "m.1.5.x", true},
+		{"m[1][*].x", "m.0.5.x", false},
+		{"[0].a", "0.a", true},
+		{"**.processors[*].add_fields", "inputs.0.processors.1.add_fields", true},
+		{`labels["app.kubernetes.io/name"]`, `labels["app.kubernetes.io/name"]`, true},
+		{`labels["app.kubernetes.io/name"]`, "labels.app.kubernetes.io/name", false},
+		{`labels['a.b'].c`, `labels["a.b"].c`, true},
+		{`labels["*"]`, "labels.x", false},
+		{`labels["*"]`, `labels["*"]`, true},
+		{"labels.*", `labels["app.kubernetes.io/name"]`, true},
+		{"**.*password*", `auth["db.password"]`, true},
+		{"**.password", `auth["db.password"]`, false},
+		{`["a.b"][0]`, `["a.b"].0`, true},
+	}
+
+	for _, c := range cases {
+		p, err := CompilePathPattern(c.pattern)
+		if assert.NoError(t, err, c.pattern) {
+			assert.Equal(t, c.match, p.Match(c.path), "%v ~ %v", c.pattern, c.path)
+			assert.Equal(t, c.pattern, p.String())
+		}
+	}
+}
+
+func TestPathPatternInvalid(t *testing.T) {
+	patterns := []string{
+		"",
+		"a..b",
+		"a.",
+		"a[",
+		"a[x]",
+		"a[-1]",
+		"a]",
+		"a[0]b",
+		"**[0]",
+		`a["b]`,
+		`a["b"c]`,
+	}
+
+	for _, pattern := range patterns {
+		_, err := CompilePathPattern(pattern)
+		if assert.Error(t, err, pattern) {
+			assert.Equal(t, ErrInvalidPathPattern, err.(Error).Reason())
+		}
+	}
+
+	assert.Panics(t, func() { MustCompilePathPattern("a[") })
+}
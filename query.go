@@ -0,0 +1,589 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ucfg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// QueryResult is a setting selected by Query.
+type QueryResult struct {
+	// Path is the absolute path of the setting within the queried
+	// configuration, with array indices represented by their number. Names
+	// containing the separator or brackets are quoted, such that Path can be
+	// passed to Get.
+	Path  string
+	Kind  Kind
+	Value interface{}
+	Meta  *Meta
+}
+
+// Query selects all settings in c matching the JSONPath like expression expr.
+// An expression is a sequence of steps, optionally starting with '$' for the
+// configuration c itself:
+//
+//	name, .name        selects the field name. Numeric names select an array
+//	                   index, like in the paths accepted by Get and Set.
+//	['name']           selects the field name, which may contain any character.
+//	*, .*, [*]         selects all fields and array elements.
+//	[n]                selects the array index n. Negative indices count from
+//	                   the end of the array (e.g. '[-1]' selects the last element).
+//	..name, ..*, ..[n] recursive descent, applying the step to the current
+//	                   setting and all of its descendants. The matches of a
+//	                   setting are selected before the matches of its children.
+//	[?(@.path op lit)] selects all fields and array elements for which the
+//	                   filter holds. The operator op is one of ==, !=, <, <=,
+//	                   >, >=, and lit is a quoted string, number, true, false
+//	                   or null. Without operator and literal (e.g. '[?(@.id)]'),
+//	                   the filter checks that the setting at path exists.
+//
+// Examples:
+//
+//	outputs.*.hosts
+//	processors[*].add_fields
+//	..ssl
+//	inputs[?(@.enabled==true)].paths[-1]
+//
+// Settings that do not exist or have an incompatible type are not selected,
+// and filters on missing settings do not hold.
+// Variables are resolved, and an error is returned if a variable on the
+// selected paths can not be resolved. Dictionaries and arrays are returned as
+// *Config and primitive values as reported by Walk. The result paths are
+// separated by PathSep or '.' by default.
+//
+// Query supports the options: PathSep, Env, Resolve, ResolveEnv
+func (c *Config) Query(expr string, opts ...Option) ([]QueryResult, error) {
+	steps, err := parseQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	o := makeOptions(opts)
+	nodes := []queryNode{{v: cfgSub{c}}}
+	for _, step := range steps {
+		var next []queryNode
+		for _, node := range nodes {
+			next, err = step.apply(o, node, next)
+			if err != nil {
+				return nil, err
+			}
+		}
+		nodes = next
+	}
+
+	sep := o.pathSep
+	if sep == "" {
+		sep = "."
+	}
+
+	results := make([]QueryResult, 0, len(nodes))
+	for _, node := range nodes {
+		path := cfgPath{fields: node.path, sep: sep}
+		r, err := makeQueryResult(o, path.String(), node.v)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+type queryNode struct {
+	path []field
+	v    value
+}
+
+// queryStep selects the matching children of node, appending them to out.
+type queryStep interface {
+	apply(opts *options, node queryNode, out []queryNode) ([]queryNode, error)
+}
+
+type queryNameStep struct{ field namedField }
+
+type queryIndexStep struct{ idx int }
+
+type queryWildcardStep struct{}
+
+type queryDescendStep struct{ step queryStep }
+
+type queryFilterStep struct{ filter queryFilter }
+
+type queryFilter struct {
+	path cfgPath
+	op   string
+	lit  interface{}
+}
+
+func (n queryNode) child(f field, v value) queryNode {
+	path := make([]field, len(n.path), len(n.path)+1)
+	copy(path, n.path)
+	return queryNode{path: append(path, f), v: v}
+}
+
+// children returns all fields and array elements of node. Nodes not being
+// dictionaries or arrays have no children.
+func (n queryNode) children(opts *options) ([]queryNode, error) {
+	cfg, err := queryConfig(opts, n.v)
+	if err != nil || cfg == nil {
+		return nil, err
+	}
+
+	var children []queryNode
+	for _, name := range cfg.fields.dictKeys() {
+		v, _ := cfg.fields.get(name)
+		children = append(children, n.child(namedField{name}, v))
+	}
+	for i, v := range cfg.fields.array() {
+		children = append(children, n.child(idxField{i}, v))
+	}
+	return children, nil
+}
+
+// queryConfig returns the configuration object stored in v, or nil if v is no
+// dictionary or array.
+func queryConfig(opts *options, v value) (*Config, error) {
+	if d, ok := v.(*cfgDynamic); ok {
+		resolved, err := d.getValue(opts)
+		if err != nil {
+			return nil, err
+		}
+		v = resolved
+	}
+
+	if sub, ok := v.(cfgSub); ok {
+		return sub.c, nil
+	}
+	return nil, nil
+}
+
+func (s queryNameStep) apply(opts *options, node queryNode, out []queryNode) ([]queryNode, error) {
+	cfg, err := queryConfig(opts, node.v)
+	if err != nil || cfg == nil {
+		return out, err
+	}
+
+	v, err := s.field.GetValue(opts, cfgSub{cfg})
+	if err != nil || v == nil {
+		return out, nil
+	}
+	return append(out, node.child(s.field, v)), nil
+}
+
+func (s queryIndexStep) apply(opts *options, node queryNode, out []queryNode) ([]queryNode, error) {
+	cfg, err := queryConfig(opts, node.v)
+	if err != nil || cfg == nil {
+		return out, err
+	}
+
+	arr := cfg.fields.array()
+	idx := s.idx
+	if idx < 0 {
+		idx += len(arr)
+	}
+	if idx < 0 || idx >= len(arr) {
+		return out, nil
+	}
+	return append(out, node.child(idxField{idx}, arr[idx])), nil
+}
+
+func (queryWildcardStep) apply(opts *options, node queryNode, out []queryNode) ([]queryNode, error) {
+	children, err := node.children(opts)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, children...), nil
+}
+
+func (s queryDescendStep) apply(opts *options, node queryNode, out []queryNode) ([]queryNode, error) {
+	out, err := s.step.apply(opts, node, out)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only descend into sub-configurations, but not into references, such that
+	// settings are visited at most once.
+	if _, ok := node.v.(cfgSub); !ok {
+		return out, nil
+	}
+
+	children, err := node.children(opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range children {
+		if out, err = s.apply(opts, child, out); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func (s queryFilterStep) apply(opts *options, node queryNode, out []queryNode) ([]queryNode, error) {
+	children, err := node.children(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, child := range children {
+		if s.filter.match(opts, child.v) {
+			out = append(out, child)
+		}
+	}
+	return out, nil
+}
+
+func (f queryFilter) match(opts *options, v value) bool {
+	if len(f.path.fields) > 0 {
+		cfg, err := queryConfig(opts, v)
+		if err != nil || cfg == nil {
+			return false
+		}
+		v, err = f.path.GetValue(cfg, opts)
+		if err != nil || v == nil {
+			return false
+		}
+	}
+
+	if f.op == "" {
+		return true
+	}
+
+	x, err := v.reify(opts)
+	if err != nil {
+		return false
+	}
+
+	switch f.op {
+	case "==":
+		return queryEqual(x, f.lit)
+	case "!=":
+		return !queryEqual(x, f.lit)
+	}
+
+	cmp, ok := queryCompare(x, f.lit)
+	if !ok {
+		return false
+	}
+	switch f.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+func queryEqual(a, b interface{}) bool {
+	if cmp, ok := queryCompare(a, b); ok {
+		return cmp == 0
+	}
+	return a == b
+}
+
+// queryCompare compares two numbers or two strings. Returns false if the
+// values can not be ordered.
+func queryCompare(a, b interface{}) (int, bool) {
+	if sa, ok := a.(string); ok {
+		sb, ok := b.(string)
+		return strings.Compare(sa, sb), ok
+	}
+
+	fa, ok := queryNumber(a)
+	if !ok {
+		return 0, false
+	}
+	fb, ok := queryNumber(b)
+	if !ok {
+		return 0, false
+	}
+
+	switch {
+	case fa < fb:
+		return -1, true
+	case fa > fb:
+		return 1, true
+	default:
+		return 0, true
+	}
+}
+
+func queryNumber(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case int64:
+		return float64(x), true
+	case uint64:
+		return float64(x), true
+	case float64:
+		return x, true
+	default:
+		return 0, false
+	}
+}
+
+func makeQueryResult(opts *options, path string, v value) (QueryResult, error) {
+	if d, ok := v.(*cfgDynamic); ok {
+		resolved, err := d.getValue(opts)
+		if err != nil {
+			return QueryResult{}, err
+		}
+		v = resolved
+	}
+
+	if sub, ok := v.(cfgSub); ok {
+		return QueryResult{Path: path, Kind: configKind(sub.c), Value: sub.c, Meta: v.meta()}, nil
+	}
+
+	kind, x, err := walkPrimitive(v, opts)
+	if err != nil {
+		return QueryResult{}, err
+	}
+	return QueryResult{Path: path, Kind: kind, Value: x, Meta: v.meta()}, nil
+}
+
+func parseQuery(expr string) ([]queryStep, error) {
+	p := queryParser{expr: expr, in: strings.TrimPrefix(expr, "$")}
+	if p.in == "" {
+		if expr == "" {
+			return nil, p.fail("empty query")
+		}
+		return nil, nil
+	}
+
+	// a leading name is only allowed if the query does not start with '$'
+	var steps []queryStep
+	first := len(p.in) == len(expr)
+	for p.in != "" {
+		var step queryStep
+		var err error
+
+		switch {
+		case strings.HasPrefix(p.in, ".."):
+			p.in = p.in[2:]
+			if step, err = p.parseStep(); err == nil {
+				step = queryDescendStep{step}
+			}
+		case p.in[0] == '.':
+			p.in = p.in[1:]
+			if strings.HasPrefix(p.in, "[") {
+				return nil, p.fail("unexpected '['")
+			}
+			step, err = p.parseStep()
+		case p.in[0] == '[' || first:
+			step, err = p.parseStep()
+		default:
+			return nil, p.fail(fmt.Sprintf("unexpected '%v'", p.in[:1]))
+		}
+
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+		first = false
+	}
+	return steps, nil
+}
+
+type queryParser struct {
+	expr string
+	in   string
+}
+
+func (p *queryParser) fail(reason string) Error {
+	return raiseInvalidQuery(p.expr, reason)
+}
+
+// parseStep parses a name, wildcard or bracket expression.
+func (p *queryParser) parseStep() (queryStep, error) {
+	if p.in == "" {
+		return nil, p.fail("unexpected end of query")
+	}
+	if p.in[0] == '[' {
+		return p.parseBracket()
+	}
+
+	end := strings.IndexAny(p.in, ".[")
+	if end < 0 {
+		end = len(p.in)
+	}
+	name := p.in[:end]
+	p.in = p.in[end:]
+
+	switch {
+	case name == "":
+		return nil, p.fail("empty name")
+	case name == "*":
+		return queryWildcardStep{}, nil
+	case strings.ContainsAny(name, "]()?@'\"*"):
+		return nil, p.fail(fmt.Sprintf("invalid name '%v'", name))
+	}
+
+	if idx, err := strconv.ParseUint(name, 10, 31); err == nil {
+		return queryIndexStep{int(idx)}, nil
+	}
+	return queryNameStep{namedField{name}}, nil
+}
+
+func (p *queryParser) parseBracket() (queryStep, error) {
+	in := p.in[1:]
+
+	switch {
+	case strings.HasPrefix(in, "*]"):
+		p.in = in[2:]
+		return queryWildcardStep{}, nil
+
+	case strings.HasPrefix(in, "?("):
+		end := queryFilterEnd(in)
+		if end < 0 {
+			return nil, p.fail("unterminated filter")
+		}
+		filter, err := p.parseFilter(strings.TrimSpace(in[2:end]))
+		if err != nil {
+			return nil, err
+		}
+		p.in = in[end+2:]
+		return queryFilterStep{filter}, nil
+
+	case in != "" && (in[0] == '\'' || in[0] == '"'):
+		end := strings.IndexByte(in[1:], in[0]) + 1
+		if end == 0 || !strings.HasPrefix(in[end+1:], "]") {
+			return nil, p.fail("unterminated name")
+		}
+		p.in = in[end+2:]
+		return queryNameStep{namedField{in[1:end]}}, nil
+	}
+
+	end := strings.IndexByte(in, ']')
+	if end < 0 {
+		return nil, p.fail("unterminated index")
+	}
+	idx, err := strconv.ParseInt(in[:end], 10, 32)
+	if err != nil {
+		return nil, p.fail(fmt.Sprintf("invalid index '%v'", in[:end]))
+	}
+	p.in = in[end+1:]
+	return queryIndexStep{int(idx)}, nil
+}
+
+// queryFilterEnd finds the index of the closing ")]" of a filter expression,
+// ignoring quoted strings.
+func queryFilterEnd(in string) int {
+	var quote byte
+	for i := 2; i < len(in); i++ {
+		switch c := in[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ')' && i+1 < len(in) && in[i+1] == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+func (p *queryParser) parseFilter(in string) (queryFilter, error) {
+	if !strings.HasPrefix(in, "@") {
+		return queryFilter{}, p.fail("filter must start with '@'")
+	}
+	in = in[1:]
+
+	lhs, op, rhs := in, "", ""
+	if i, candidate := queryFilterOp(in); i >= 0 {
+		lhs, op, rhs = in[:i], candidate, in[i+len(candidate):]
+	}
+
+	var filter queryFilter
+	lhs = strings.TrimSpace(lhs)
+	if lhs != "" {
+		if !strings.HasPrefix(lhs, ".") || len(lhs) == 1 {
+			return queryFilter{}, p.fail(fmt.Sprintf("invalid filter path '@%v'", lhs))
+		}
+		filter.path = parsePath(lhs[1:], ".", defaultMaxIdx, false, false)
+	}
+	if op == "" {
+		if lhs == "" {
+			return queryFilter{}, p.fail("empty filter")
+		}
+		return filter, nil
+	}
+
+	lit, err := parseQueryLiteral(strings.TrimSpace(rhs))
+	if err != nil {
+		return queryFilter{}, p.fail(err.Error())
+	}
+	filter.op = op
+	filter.lit = lit
+	return filter, nil
+}
+
+// queryFilterOp finds the first comparison operator in a filter expression,
+// ignoring quoted strings. Returns -1 if the expression has no operator.
+func queryFilterOp(in string) (int, string) {
+	var quote byte
+	for i := 0; i < len(in); i++ {
+		switch c := in[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		default:
+			for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+				if strings.HasPrefix(in[i:], op) {
+					return i, op
+				}
+			}
+		}
+	}
+	return -1, ""
+}
+
+func parseQueryLiteral(in string) (interface{}, error) {
+	switch in {
+	case "":
+		return nil, fmt.Errorf("missing literal")
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+
+	if len(in) >= 2 && (in[0] == '\'' || in[0] == '"') && in[len(in)-1] == in[0] {
+		return in[1 : len(in)-1], nil
+	}
+	if i, err := strconv.ParseInt(in, 10, 64); err == nil {
+		return i, nil
+	}
+	if u, err := strconv.ParseUint(in, 10, 64); err == nil {
+		return u, nil
+	}
+	if f, err := strconv.ParseFloat(in, 64); err == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("invalid literal '%v'", in)
+}
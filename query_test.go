@@ -0,0 +1,171 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ucfg
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuery(t *testing.T) {
+	c := MustNewFrom(MapSlice{
+		{"outputs", MapSlice{
+			{"elasticsearch", MapSlice{
+				{"hosts", []interface{}{"es1", "es2"}},
+				{"ssl", MapSlice{{"enabled", true}}},
+			}},
+			{"logstash", MapSlice{
+				{"hosts", []interface{}{"ls1"}},
+			}},
+			{"console", MapSlice{{"pretty", true}}},
+		}},
+		{"processors", []interface{}{
+			MapSlice{{"add_fields", MapSlice{{"a", 1}}}},
+			MapSlice{{"drop_event", MapSlice{{"when", "x"}}}},
+			MapSlice{{"add_fields", MapSlice{{"b", 2}}}},
+		}},
+		{"inputs", []interface{}{
+			MapSlice{{"id", "a"}, {"enabled", true}, {"port", 5044}},
+			MapSlice{{"id", "b"}, {"enabled", false}, {"port", 9000}},
+			MapSlice{{"id", "c"}, {"port", "${default_port}"}, {"ssl", MapSlice{{"enabled", false}}}},
+		}},
+		{"default_port", 8080},
+		{"dotted.name", "v"},
+	}, VarExp)
+
+	cases := map[string]struct {
+		query string
+		paths []string
+	}{
+		"field":              {"outputs.logstash.hosts", []string{"outputs.logstash.hosts"}},
+		"root":               {"$.default_port", []string{"default_port"}},
+		"wildcard":           {"outputs.*.hosts", []string{"outputs.elasticsearch.hosts", "outputs.logstash.hosts"}},
+		"bracket wildcard":   {"processors[*].add_fields", []string{"processors.0.add_fields", "processors.2.add_fields"}},
+		"numeric name":       {"processors.1.drop_event.when", []string{"processors.1.drop_event.when"}},
+		"index":              {"outputs.elasticsearch.hosts[1]", []string{"outputs.elasticsearch.hosts.1"}},
+		"negative index":     {"processors[-1].add_fields.b", []string{"processors.2.add_fields.b"}},
+		"index out of range": {"processors[-4]", nil},
+		"missing":            {"outputs.kafka.hosts", nil},
+		"no object":          {"default_port.x", nil},
+		"quoted name":        {"$['dotted.name']", []string{`["dotted.name"]`}},
+		"recursive descent":  {"..ssl", []string{"outputs.elasticsearch.ssl", "inputs.2.ssl"}},
+		"recursive wildcard": {"outputs..*", []string{
+			"outputs.elasticsearch", "outputs.logstash", "outputs.console",
+			"outputs.elasticsearch.hosts", "outputs.elasticsearch.ssl",
+			"outputs.elasticsearch.hosts.0", "outputs.elasticsearch.hosts.1",
+			"outputs.elasticsearch.ssl.enabled",
+			"outputs.logstash.hosts", "outputs.logstash.hosts.0",
+			"outputs.console.pretty",
+		}},
+		"recursive index":      {"outputs..hosts[0]", []string{"outputs.elasticsearch.hosts.0", "outputs.logstash.hosts.0"}},
+		"filter bool":          {"inputs[?(@.enabled==true)].id", []string{"inputs.0.id"}},
+		"filter not equal":     {"inputs[?(@.enabled != true)].id", []string{"inputs.1.id"}},
+		"filter string":        {"inputs[?(@.id=='b')]", []string{"inputs.1"}},
+		"filter number":        {"inputs[?(@.port>=8080)].id", []string{"inputs.1.id", "inputs.2.id"}},
+		"filter exists":        {"inputs[?(@.enabled)].id", []string{"inputs.0.id", "inputs.1.id"}},
+		"filter nested path":   {"inputs[?(@.ssl.enabled==false)].id", []string{"inputs.2.id"}},
+		"filter dict values":   {"outputs[?(@.pretty==true)]", []string{"outputs.console"}},
+		"filter self":          {"outputs.elasticsearch.hosts[?(@=='es2')]", []string{"outputs.elasticsearch.hosts.1"}},
+		"filter type mismatch": {"inputs[?(@.id>1)]", nil},
+		"filter quoted op":     {"inputs[?(@.id<'x==y')].id", []string{"inputs.0.id", "inputs.1.id", "inputs.2.id"}},
+		"filter quoted equal":  {"inputs[?(@.id=='a>b')].id", nil},
+	}
+
+	for name, test := range cases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			results, err := c.Query(test.query)
+			require.NoError(t, err)
+
+			var paths []string
+			for _, r := range results {
+				paths = append(paths, r.Path)
+			}
+			assert.Equal(t, test.paths, paths)
+		})
+	}
+}
+
+func TestQueryValues(t *testing.T) {
+	c := MustNewFrom(MapSlice{
+		{"a", MapSlice{{"b", []interface{}{1, "${c}"}}}},
+		{"c", "x"},
+	}, VarExp, MetaData(Meta{Source: "test.yml"}))
+
+	results, err := c.Query("a.b", PathSep("/"))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "a/b", results[0].Path)
+	assert.Equal(t, KindArray, results[0].Kind)
+	assert.Equal(t, "test.yml", results[0].Meta.Source)
+	sub := results[0].Value.(*Config)
+	n, err := sub.CountField("")
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	results, err = c.Query("a.b[*]", PathSep("/"))
+	require.NoError(t, err)
+	assert.Equal(t, []QueryResult{
+		{Path: "a/b/0", Kind: KindUint, Value: uint64(1), Meta: &Meta{Source: "test.yml"}},
+		{Path: "a/b/1", Kind: KindString, Value: "x", Meta: &Meta{Source: "test.yml"}},
+	}, results)
+
+	results, err = c.Query("$")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "", results[0].Path)
+	assert.True(t, results[0].Value.(*Config) == c)
+}
+
+func TestQueryUnresolvedVariable(t *testing.T) {
+	c := MustNewFrom(map[string]interface{}{
+		"a": "${missing:?not set}",
+	}, VarExp)
+
+	_, err := c.Query("a")
+	assert.Error(t, err)
+}
+
+func TestQueryInvalid(t *testing.T) {
+	queries := []string{
+		"",
+		"a..",
+		"a.",
+		"$a",
+		"a[",
+		"a[x]",
+		"a['b",
+		"a[?(@.b==1]",
+		"a[?(b==1)]",
+		"a[?(@.b==)]",
+		"a[?(@.b==x)]",
+		"a[?(@)]",
+		"a.[0]",
+		"a]",
+	}
+
+	for _, query := range queries {
+		_, err := New().Query(query)
+		if assert.Error(t, err, query) {
+			assert.True(t, errors.Is(err, ErrInvalidQuery), query)
+		}
+	}
+}
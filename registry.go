@@ -0,0 +1,241 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ucfg
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// typeRegistry stores the constructors registered via RegisterType, indexed
+// by the interface type and the type name.
+type typeRegistry struct {
+	mu    sync.RWMutex
+	types map[reflect.Type]map[string]func() reflect.Value
+}
+
+const defaultDiscriminator = "type"
+
+var registeredTypes = &typeRegistry{
+	types: map[reflect.Type]map[string]func() reflect.Value{},
+}
+
+// RegisterType registers a constructor for a named implementation of the
+// interface type T. When unpacking into a field, slice element or map value
+// of type T, Unpack reads the discriminator field (default "type",
+// configurable via the `discriminator=<field>` struct tag option) from the
+// config object, creates a new value by calling the constructor registered
+// for the name found and unpacks the config object into the new value.
+//
+// RegisterType returns ErrTypeNoInterface if T is no interface type and
+// ErrDuplicateType if a constructor with the same name is already registered
+// for T. Unpack fails with ErrNilInstance if the constructor returns nil.
+//
+// Example:
+//
+//	ucfg.RegisterType[Output]("kafka", func() Output { return &KafkaOutput{} })
+//
+//	type Settings struct {
+//		Outputs []Output `config:"outputs,discriminator=type"`
+//	}
+//
+// If the struct tag option `typekey` is set, the implementation is selected
+// by the only key of the config object instead. The value of the key is
+// unpacked into the new value:
+//
+//	processors:
+//	  - add_fields: {target: project, fields: {name: test}}
+//	  - drop_event:
+//
+//	type Settings struct {
+//		Processors []Processor `config:"processors,typekey"`
+//	}
+func RegisterType[T any](name string, constructor func() T) error {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Interface {
+		return ErrTypeNoInterface
+	}
+
+	return registeredTypes.register(t, name, func() reflect.Value {
+		v := reflect.New(t).Elem()
+		if inst := reflect.ValueOf(constructor()); inst.IsValid() {
+			v.Set(inst)
+		}
+		return v
+	})
+}
+
+func (r *typeRegistry) register(t reflect.Type, name string, fn func() reflect.Value) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	named := r.types[t]
+	if named == nil {
+		named = map[string]func() reflect.Value{}
+		r.types[t] = named
+	}
+
+	if _, exists := named[name]; exists {
+		return ErrDuplicateType
+	}
+	named[name] = fn
+	return nil
+}
+
+func (r *typeRegistry) has(t reflect.Type) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.types[t]) > 0
+}
+
+func (r *typeRegistry) lookup(t reflect.Type, name string) (func() reflect.Value, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	fn, ok := r.types[t][name]
+	return fn, ok
+}
+
+func (r *typeRegistry) names(t reflect.Type) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.types[t]))
+	for name := range r.types[t] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isRegisteredType checks if t is an interface type (or a pointer to an
+// interface type) with implementations registered via RegisterType.
+func isRegisteredType(t reflect.Type) bool {
+	t = chaseTypePointers(t)
+	return t.Kind() == reflect.Interface && registeredTypes.has(t)
+}
+
+// reifyRegisteredType creates and unpacks the implementation of the interface
+// type t selected by the discriminator field in val.
+func reifyRegisteredType(
+	opts fieldOptions,
+	t reflect.Type,
+	val value,
+) (reflect.Value, Error) {
+	baseType := chaseTypePointers(t)
+	if isNil(val) {
+		return pointerize(t, baseType, reflect.Zero(baseType)), nil
+	}
+
+	sub, err := val.toConfig(opts.opts)
+	if err != nil {
+		return reflect.Value{}, raiseExpectedObject(opts.opts, val)
+	}
+
+	if opts.opts.typeKey {
+		v, err := reifyRegisteredTypeKey(opts, baseType, sub)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return pointerize(t, baseType, v), nil
+	}
+
+	field := opts.opts.discriminator
+	if field == "" {
+		field = defaultDiscriminator
+	}
+
+	tagValue, ok := sub.fields.get(field)
+	if !ok || isNil(tagValue) {
+		return reflect.Value{}, raiseMissingDiscriminator(sub, field)
+	}
+	name, err := tagValue.toString(opts.opts)
+	if err != nil {
+		return reflect.Value{}, raiseConversion(opts.opts, tagValue, err, "string")
+	}
+
+	v, rerr := reifyRegisteredInstance(opts, baseType, name, tagValue, val)
+	if rerr != nil {
+		return reflect.Value{}, rerr
+	}
+	return pointerize(t, baseType, v), nil
+}
+
+// reifyRegisteredTypeKey unpacks the externally tagged form, a config object
+// with exactly one key naming the implementation to unpack the key its value
+// into.
+func reifyRegisteredTypeKey(
+	opts fieldOptions,
+	t reflect.Type,
+	sub *Config,
+) (reflect.Value, Error) {
+	dict := sub.fields.dict()
+	if len(dict) != 1 || len(sub.fields.array()) > 0 {
+		return reflect.Value{}, raiseTypeKeyCount(sub, len(dict)+len(sub.fields.array()))
+	}
+
+	for name, val := range dict {
+		return reifyRegisteredInstance(opts, t, name, val, val)
+	}
+	return reflect.Value{}, nil
+}
+
+// reifyRegisteredInstance creates a new instance of the implementation named
+// name and unpacks val into it. The ref value is used for error reporting if
+// name is unknown or its constructor returns nil.
+func reifyRegisteredInstance(
+	opts fieldOptions,
+	t reflect.Type,
+	name string,
+	ref, val value,
+) (reflect.Value, Error) {
+	constructor, ok := registeredTypes.lookup(t, name)
+	if !ok {
+		return reflect.Value{}, raiseUnknownType(ref, t, name, registeredTypes.names(t))
+	}
+
+	inst := constructor()
+	if inst.IsNil() {
+		return reflect.Value{}, raiseNilInstance(ref, t, name)
+	}
+
+	// Unpack into an addressable copy of the concrete value, so struct values
+	// returned by the constructor can be updated as well.
+	concrete := inst.Elem()
+	tmp := reflect.New(concrete.Type()).Elem()
+	tmp.Set(concrete)
+
+	fopts := fieldOptions{opts: opts.opts}
+	v, err := reifyMergeValue(fopts, tmp, val)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if !v.IsValid() {
+		v = tmp
+	}
+
+	out := reflect.New(t).Elem()
+	out.Set(v)
+
+	if !opts.opts.noValidate {
+		if err := runValidators(out.Interface(), opts.validators); err != nil {
+			return reflect.Value{}, raiseValidation(val.Context(), val.meta(), "", err)
+		}
+	}
+	return out, nil
+}
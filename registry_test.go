@@ -0,0 +1,274 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ucfg
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testOutput interface {
+	Name() string
+}
+
+type testKafkaOutput struct {
+	Hosts []string
+	Topic string
+}
+
+type testFileOutput struct {
+	Path string `validate:"required"`
+}
+
+type testConsoleOutput struct {
+	Pretty bool
+}
+
+func (o *testKafkaOutput) Name() string  { return "kafka" }
+func (o *testFileOutput) Name() string   { return "file" }
+func (o testConsoleOutput) Name() string { return "console" }
+
+func init() {
+	for name, fn := range map[string]func() testOutput{
+		"kafka":   func() testOutput { return &testKafkaOutput{Topic: "default"} },
+		"file":    func() testOutput { return &testFileOutput{} },
+		"console": func() testOutput { return testConsoleOutput{} },
+	} {
+		if err := RegisterType(name, fn); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func TestRegisterTypeErrors(t *testing.T) {
+	err := RegisterType("kafka", func() testOutput { return &testKafkaOutput{} })
+	assert.Equal(t, ErrDuplicateType, err)
+
+	err = RegisterType("int", func() int { return 0 })
+	assert.Equal(t, ErrTypeNoInterface, err)
+}
+
+func TestUnpackRegisteredTypeField(t *testing.T) {
+	c, _ := NewFrom(map[string]interface{}{
+		"output": map[string]interface{}{
+			"type":  "kafka",
+			"hosts": []string{"a:9092", "b:9092"},
+		},
+	})
+
+	var settings struct {
+		Output testOutput
+	}
+	require.NoError(t, c.Unpack(&settings))
+
+	kafka, ok := settings.Output.(*testKafkaOutput)
+	require.True(t, ok, "unexpected type %T", settings.Output)
+	assert.Equal(t, []string{"a:9092", "b:9092"}, kafka.Hosts)
+	assert.Equal(t, "default", kafka.Topic)
+}
+
+func TestUnpackRegisteredTypeCollections(t *testing.T) {
+	c, _ := NewFrom(map[string]interface{}{
+		"outputs": []interface{}{
+			map[string]interface{}{"kind": "file", "path": "/tmp/out"},
+			map[string]interface{}{"kind": "console", "pretty": true},
+		},
+		"named": map[string]interface{}{
+			"main": map[string]interface{}{"kind": "kafka", "topic": "logs"},
+		},
+	})
+
+	var settings struct {
+		Outputs []testOutput          `config:"outputs,discriminator=kind"`
+		Named   map[string]testOutput `config:"named,discriminator=kind"`
+	}
+	require.NoError(t, c.Unpack(&settings))
+
+	require.Len(t, settings.Outputs, 2)
+	assert.Equal(t, &testFileOutput{Path: "/tmp/out"}, settings.Outputs[0])
+	assert.Equal(t, testConsoleOutput{Pretty: true}, settings.Outputs[1])
+
+	require.Contains(t, settings.Named, "main")
+	assert.Equal(t, &testKafkaOutput{Topic: "logs"}, settings.Named["main"])
+}
+
+func TestUnpackRegisteredTypeFail(t *testing.T) {
+	tests := map[string]struct {
+		cfg     map[string]interface{}
+		reason  error
+		message string
+	}{
+		"unknown type": {
+			cfg:     map[string]interface{}{"output": map[string]interface{}{"type": "redis"}},
+			reason:  ErrUnknownType,
+			message: "unknown type 'redis' for 'ucfg.testOutput' (registered: console, file, kafka) accessing 'output.type'",
+		},
+		"missing discriminator": {
+			cfg:     map[string]interface{}{"output": map[string]interface{}{"path": "/tmp"}},
+			reason:  ErrMissing,
+			message: "missing type discriminator field 'type' accessing 'output.type'",
+		},
+		"no object": {
+			cfg:     map[string]interface{}{"output": "kafka"},
+			reason:  ErrExpectedObject,
+			message: "required 'object', but found 'string' in field 'output'",
+		},
+		"validation": {
+			cfg:     map[string]interface{}{"output": map[string]interface{}{"type": "file"}},
+			reason:  ErrStringEmpty,
+			message: "string value is not set accessing 'output.path'",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, _ := NewFrom(test.cfg)
+
+			var settings struct {
+				Output testOutput
+			}
+			err := c.Unpack(&settings)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, test.reason), "unexpected error: %v", err)
+			assert.Equal(t, test.message, err.Error())
+		})
+	}
+}
+
+func TestUnpackRegisteredTypeUnknownMessage(t *testing.T) {
+	c, _ := NewFrom(map[string]interface{}{
+		"outputs": []interface{}{
+			map[string]interface{}{"type": "redis"},
+		},
+	}, PathSep("."))
+
+	var settings struct {
+		Outputs []testOutput
+	}
+	err := c.Unpack(&settings)
+	require.Error(t, err)
+	assert.Equal(t, "outputs.0.type", err.(Error).Path())
+	assert.Contains(t, err.Error(), "unknown type 'redis'")
+	assert.Contains(t, err.Error(), "registered: console, file, kafka")
+}
+
+type testProcessor interface {
+	Run(event map[string]interface{})
+}
+
+type testAddFields struct {
+	Target string
+	Fields map[string]interface{}
+}
+
+type testDropEvent struct {
+	initialized bool
+}
+
+func (p *testAddFields) Run(event map[string]interface{}) { event[p.Target] = p.Fields }
+func (p *testDropEvent) Run(event map[string]interface{}) {}
+func (p *testDropEvent) InitDefaults()                    { p.initialized = true }
+
+func init() {
+	if err := RegisterType("add_fields", func() testProcessor { return &testAddFields{} }); err != nil {
+		panic(err)
+	}
+	if err := RegisterType("drop_event", func() testProcessor { return &testDropEvent{} }); err != nil {
+		panic(err)
+	}
+	if err := RegisterType("nil_processor", func() testProcessor { return nil }); err != nil {
+		panic(err)
+	}
+}
+
+func TestUnpackRegisteredTypeKey(t *testing.T) {
+	c, _ := NewFrom(map[string]interface{}{
+		"processors": []interface{}{
+			map[string]interface{}{
+				"add_fields": map[string]interface{}{
+					"target": "project",
+					"fields": map[string]interface{}{"name": "test"},
+				},
+			},
+			map[string]interface{}{"drop_event": nil},
+		},
+		"output": map[string]interface{}{"type": "console"},
+	})
+
+	var settings struct {
+		Processors []testProcessor `config:"processors,typekey"`
+		Output     testOutput      `config:"output"`
+	}
+	require.NoError(t, c.Unpack(&settings))
+
+	require.Len(t, settings.Processors, 2)
+	assert.Equal(t, &testAddFields{
+		Target: "project",
+		Fields: map[string]interface{}{"name": "test"},
+	}, settings.Processors[0])
+	assert.Equal(t, &testDropEvent{initialized: true}, settings.Processors[1])
+	assert.Equal(t, testConsoleOutput{}, settings.Output)
+}
+
+func TestUnpackRegisteredTypeKeyFail(t *testing.T) {
+	tests := map[string]struct {
+		processor interface{}
+		reason    error
+		message   string
+	}{
+		"no keys": {
+			processor: map[string]interface{}{},
+			reason:    ErrTypeKeyCount,
+			message:   "exactly one key selecting the type required, but found 0 accessing 'processors.0'",
+		},
+		"multiple keys": {
+			processor: map[string]interface{}{"add_fields": nil, "drop_event": nil},
+			reason:    ErrTypeKeyCount,
+			message:   "exactly one key selecting the type required, but found 2 accessing 'processors.0'",
+		},
+		"unknown type": {
+			processor: map[string]interface{}{"drop_fields": nil},
+			reason:    ErrUnknownType,
+			message:   "unknown type 'drop_fields' for 'ucfg.testProcessor' (registered: add_fields, drop_event, nil_processor) accessing 'processors.0.drop_fields'",
+		},
+		"constructor returns nil": {
+			processor: map[string]interface{}{"nil_processor": nil},
+			reason:    ErrNilInstance,
+			message:   "constructor of type 'nil_processor' for 'ucfg.testProcessor' returned nil accessing 'processors.0.nil_processor'",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, _ := NewFrom(map[string]interface{}{
+				"processors": []interface{}{test.processor},
+			})
+
+			var settings struct {
+				Processors []testProcessor `config:"processors,typekey"`
+			}
+			err := c.Unpack(&settings)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, test.reason), "unexpected error: %v", err)
+			assert.Equal(t, test.message, err.Error())
+		})
+	}
+}
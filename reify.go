@@ -18,8 +18,17 @@
 package ucfg
 
 import (
+	"encoding"
+	"encoding/json"
+	"math"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
 	"reflect"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -27,11 +36,13 @@ import (
 // and pointers as necessary.
 //
 // Unpack supports the options: PathSep, StructTag, ValidatorTag, Env, Resolve,
-// ResolveEnv, ReplaceValues, AppendValues, PrependValues.
+// ResolveEnv, ReplaceValues, AppendValues, PrependValues, MergePatch,
+// DeprecatedTag, OnDeprecated, Validators.
 //
-// When unpacking into a value, Unpack first will try to call Unpack if the
-// value implements the Unpacker interface. Otherwise, Unpack tries to convert
-// the internal value into the target type:
+// When unpacking into a value, Unpack first will use the converter registered
+// for the target type via the Converter option. Next Unpack will try to call
+// Unpack if the value implements the Unpacker interface. Otherwise, Unpack
+// tries to convert the internal value into the target type:
 //
 //	# Primitive types
 //
@@ -51,9 +62,23 @@ import (
 //	     parsed into time.Duration via time.ParseDuration.
 //	*regexp.Regexp: requires a string being compiled into a regular expression
 //	     using regexp.Compile.
+//	url.URL: requires a string parsed via url.Parse.
+//	net.IP, netip.Addr: requires a string holding an IPv4 or IPv6 address.
+//	net.IPNet, netip.Prefix: requires a string in CIDR notation.
+//	time.Time: requires a string in RFC3339 format.
+//	*time.Location: requires a location name loaded via time.LoadLocation.
+//	os.FileMode: requires a number or a string holding an octal number
+//	     (e.g. "0644").
+//	ByteSize: requires a number of bytes or a string with an SI or IEC unit
+//	     (e.g. "10MiB", "1.5GB").
 //	*Config: requires a Config object to be stored by pointer into the target
 //	     value. Can be used to capture a sub-Config without interpreting
 //	     the settings yet.
+//	encoding.TextUnmarshaler: types implementing UnmarshalText (e.g. net.IP,
+//	     netip.Prefix, big.Int, slog.Level) are unpacked from string values.
+//	     Numeric target types are still unpacked from numbers directly.
+//	json.Unmarshaler: types implementing UnmarshalJSON, but not UnmarshalText,
+//	     are unpacked from the JSON encoding of primitive values.
 //
 //	 # Arrays/Slices:
 //
@@ -80,8 +105,27 @@ import (
 //	convertible fields are replaced by the new values.
 //	If the tag options `append` or `prepend` is used, arrays will be merged by
 //	appending/prepending the new array contents.
-//	The struct tag options `replace`, `append`, and `prepend` overwrites the
+//	If the tag option `mergepatch` is used, *ucfg.Config convertible fields are
+//	merged following the JSON Merge Patch semantics (see MergePatch).
+//	If the tag option `mergekey=<name>` is used, array elements with the same
+//	value in the field <name> are merged. Other elements are appended.
+//	The struct tag options `replace`, `append`, `prepend`, and `mergepatch` overwrites the
 //	global value merging strategy (e.g. ReplaceValues, AppendValues, ...) for all sub-fields.
+//	If the tag option `alias=<name>` is used, the field is also read from the
+//	setting <name>, e.g. after the setting has been renamed. The option can be
+//	repeated. Unpack fails with ErrConflict if more than one name is set.
+//	If the `deprecated` struct tag (configured by DeprecatedTag) is set, the
+//	field is marked as deprecated. The use of deprecated fields and of aliases
+//	is reported via the OnDeprecated option, with the tag text as message.
+//
+//	# Interfaces
+//
+//	Interface types with implementations registered via RegisterType require
+//	a Config object. The implementation is selected by the value of the
+//	discriminator field (default "type"). The discriminator field can be
+//	configured per struct field using the `discriminator=<name>` tag option.
+//	If the tag option `typekey` is set, the Config object must have exactly one
+//	key naming the implementation, with the key its value being unpacked.
 //
 // When unpacking into a map, primitive, or struct Unpack will call InitDefaults if
 // the type implements the Initializer interface. The Initializer interface is not supported
@@ -107,7 +151,10 @@ import (
 // options will be applied to the unpacked value as well.
 //
 // Struct field validators are set using the `validate` tag (configurable by
-// ValidatorTag). Default validators options are:
+// ValidatorTag). Multiple validators are separated by commas. Commas within
+// a parameter must be escaped as '\,', which is written as
+// `validate:"pattern=^a{1\\,3}$"` in a struct tag. Validators are looked up in
+// the registry set by the Validators option. Default validators options are:
 //
 //	required: check value is set and not empty
 //	nonzero: check numeric value != 0 or string/slice not being empty
@@ -116,6 +163,38 @@ import (
 //	     <value> can be a duration.
 //	max=<value>: check numeric value <= <value>. If target type is time.Duration,
 //	   <value> can be a duration.
+//	minlen=<n>, maxlen=<n>: check the length of strings, arrays, slices or maps.
+//	unique: check array or slice not containing duplicate elements.
+//
+// The following validators ignore empty strings and check each element of
+// arrays and slices:
+//
+//	oneof=<value> ...: check value is one of the space separated values.
+//	pattern=<regexp>: check string matches the regular expression.
+//	url[=<scheme> ...]: check string is an absolute URL, optionally with one
+//	     of the space separated schemes.
+//	hostport: check string is of the form `host:port`.
+//	hostname: check string is a host name following RFC 1123.
+//	ip: check string is an IPv4 or IPv6 address.
+//	cidr: check string is an IP network in CIDR notation.
+//	port: check number or string is a port between 1 and 65535. Zero is
+//	     ignored.
+//	file_exists, dir_exists: check string is the path of an existing file or
+//	     directory.
+//
+// Cross field validators check a struct field against its sibling fields,
+// once all fields of the struct have been unpacked. Sibling fields are
+// referenced by their setting names, separated by spaces. Names of nested
+// fields are separated by '.'. A field is set if it is not nil, zero, or
+// empty:
+//
+//	required_if=<field> <value> ...: check value is set if all listed fields
+//	     have the given values.
+//	required_with=<field> ...: check value is set if any listed field is set.
+//	excluded_with=<field> ...: check value is not set if any listed field is
+//	     set.
+//	exactly_one_of=<field> ...: check exactly one of the value and the listed
+//	     fields is set.
 //
 // If a config value is not the convertible to the target type, or overflows the
 // target type, Unpack will abort immediately and return the appropriate error.
@@ -259,11 +338,17 @@ func reifyStruct(opts *options, orig reflect.Value, cfg *Config) Error {
 		to.Set(orig)
 	}
 
+	var fields []fieldInfo
 	if v, ok := valueIsUnpacker(to); ok {
 		err := unpackWith(opts, v, cfgSub{cfg})
 		if err != nil {
 			return err
 		}
+		if !opts.noValidate {
+			if fields, err = accessFields(to, opts); err != nil {
+				return err
+			}
+		}
 	} else {
 		tryInitDefaults(to)
 		numField := to.NumField()
@@ -283,19 +368,19 @@ func reifyStruct(opts *options, orig reflect.Value, cfg *Config) Error {
 				continue
 			}
 			configured.Add(fieldName(name, stField.Name))
+			for _, alias := range tagOpts.aliases {
+				configured.Add(alias)
+			}
 		}
 		opts.configuredFields = configured
 		defer func() { opts.configuredFields = parentConfigured }()
 
-		for i := 0; i < numField; i++ {
-			fInfo, skip, err := accessField(to, i, opts)
-			if err != nil {
-				return err
-			}
-			if skip {
-				continue
-			}
+		var err Error
+		if fields, err = accessFields(to, opts); err != nil {
+			return err
+		}
 
+		for _, fInfo := range fields {
 			if fInfo.tagOptions.squash {
 				vField := chaseValue(fInfo.value)
 				switch vField.Kind() {
@@ -318,10 +403,15 @@ func reifyStruct(opts *options, orig reflect.Value, cfg *Config) Error {
 				// Non-inline fields live in their own config namespace, so
 				// configuredFields from the parent struct must not filter
 				// keys inside nested maps.
+				name, err := resolveFieldName(cfg, fInfo)
+				if err != nil {
+					return err
+				}
+
 				savedConfigured := fInfo.options.configuredFields
 				fInfo.options.configuredFields = nil
 				fopts := fieldOptions{opts: fInfo.options, tag: fInfo.tagOptions, validators: fInfo.validatorTags}
-				err := reifyGetField(cfg, fopts, fInfo.name, fInfo.value, fInfo.ftype)
+				err = reifyGetField(cfg, fopts, name, fInfo.value, fInfo.ftype)
 				fInfo.options.configuredFields = savedConfigured
 				if err != nil {
 					return err
@@ -331,6 +421,13 @@ func reifyStruct(opts *options, orig reflect.Value, cfg *Config) Error {
 	}
 
 	if !opts.noValidate {
+		if field, err := validateCrossFields(fields); err != nil {
+			meta := cfg.metadata
+			if v, _ := lookupFieldName(cfg, field, opts); v != nil {
+				meta = v.meta()
+			}
+			return raiseValidation(cfg.ctx, meta, field, err)
+		}
 		if err := tryValidate(to); err != nil {
 			return raiseValidation(cfg.ctx, cfg.metadata, "", err)
 		}
@@ -347,7 +444,7 @@ func reifyGetField(
 	to reflect.Value,
 	fieldType reflect.Type,
 ) Error {
-	p := parsePathWithOpts(name, opts.opts)
+	p := parseKeyPath(name, opts.opts)
 	value, err := p.GetValue(cfg, opts.opts)
 	if err != nil {
 		if err.Reason() != ErrMissing {
@@ -401,11 +498,19 @@ func reifyValue(
 	t reflect.Type,
 	val value,
 ) (reflect.Value, Error) {
+	if v, ok, err := reifyConverter(opts, t, val); ok {
+		return v, err
+	}
+
+	if isRegisteredType(t) {
+		return reifyRegisteredType(opts, t, val)
+	}
+
 	if t.Kind() == reflect.Interface && t.NumMethod() == 0 {
 		reified, err := val.reify(opts.opts)
 		if err != nil {
 			ctx := val.Context()
-			return reflect.Value{}, raisePathErr(err, val.meta(), "", ctx.path("."))
+			return reflect.Value{}, raisePathErr(err, val.meta(), "", ctx.structuredPath())
 		}
 		return reflect.ValueOf(reified), nil
 	}
@@ -426,6 +531,10 @@ func reifyValue(
 		return v, nil
 	}
 
+	if isPrimitiveType(baseType) && isPrimitiveValue(opts.opts, val) {
+		return reifyPrimitive(opts, val, t, baseType)
+	}
+
 	if baseType.Kind() == reflect.Struct {
 		sub, err := val.toConfig(opts.opts)
 		if err != nil {
@@ -477,6 +586,10 @@ func reifyMergeValue(
 ) (reflect.Value, Error) {
 	old := chaseValueInterfaces(oldValue)
 	t := old.Type()
+	if v, ok, err := reifyConverter(opts, t, val); ok {
+		return v, err
+	}
+
 	old = chaseValuePointers(old)
 	if (old.Kind() == reflect.Ptr || old.Kind() == reflect.Interface) && old.IsNil() {
 		return reifyValue(opts, t, val)
@@ -500,7 +613,7 @@ func reifyMergeValue(
 			v, err := val.reflect(opts.opts)
 			if err != nil {
 				ctx := val.Context()
-				return reflect.Value{}, raisePathErr(err, val.meta(), "", ctx.path("."))
+				return reflect.Value{}, raisePathErr(err, val.meta(), "", ctx.structuredPath())
 			}
 
 			v = v.Convert(reflect.PtrTo(baseType))
@@ -525,6 +638,10 @@ func reifyMergeValue(
 		return old, nil
 	}
 
+	if isPrimitiveType(baseType) && isPrimitiveValue(opts.opts, val) {
+		return reifyPrimitive(opts, val, t, baseType)
+	}
+
 	switch baseType.Kind() {
 	case reflect.Map:
 		sub, err := val.toConfig(opts.opts)
@@ -550,6 +667,54 @@ func reifyMergeValue(
 	return reifyPrimitive(opts, val, t, baseType)
 }
 
+// reifyConverter unpacks val using the converter registered for t or the
+// type t points to. Returns false if no converter is available.
+func reifyConverter(
+	opts fieldOptions,
+	t reflect.Type,
+	val value,
+) (reflect.Value, bool, Error) {
+	if len(opts.opts.converters) == 0 {
+		return reflect.Value{}, false, nil
+	}
+
+	baseType := t
+	conv := opts.opts.converters[t]
+	if conv == nil {
+		baseType = chaseTypePointers(t)
+		if conv = opts.opts.converters[baseType]; conv == nil {
+			return reflect.Value{}, false, nil
+		}
+	}
+
+	if isNil(val) {
+		return reflect.Zero(t), true, nil
+	}
+
+	ctx := val.Context()
+	path := ctx.path(".")
+	reified, err := val.reify(opts.opts)
+	if err != nil {
+		return reflect.Value{}, true, raisePathErr(err, val.meta(), "", ctx.structuredPath())
+	}
+
+	v, err := conv(reified, path)
+	if err != nil {
+		return reflect.Value{}, true, raiseConverter(val, baseType, err)
+	}
+
+	if !opts.opts.noValidate {
+		if err := runValidators(v.Interface(), opts.validators); err != nil {
+			return reflect.Value{}, true, raiseValidation(ctx, val.meta(), "", err)
+		}
+		if err := tryValidate(v); err != nil {
+			return reflect.Value{}, true, raiseValidation(ctx, val.meta(), "", err)
+		}
+	}
+
+	return pointerize(t, baseType, v), true, nil
+}
+
 func mergeFieldConfig(opts fieldOptions, to, from *Config) Error {
 	return mergeConfig(opts.opts, to, from)
 }
@@ -591,6 +756,9 @@ func reifySliceMerge(
 	}
 
 	arrMergeCfg := opts.configHandling()
+	if arrMergeCfg == cfgMergeByKey && old.IsValid() && !old.IsNil() {
+		return reifySliceMergeByKey(opts, old, tTo, val, arr)
+	}
 
 	l := len(arr)
 	start := 0
@@ -601,7 +769,7 @@ func reifySliceMerge(
 		ol := old.Len()
 
 		switch arrMergeCfg {
-		case cfgReplaceValue:
+		case cfgReplaceValue, cfgMergePatch:
 			// do nothing
 
 		case cfgArrAppend:
@@ -626,6 +794,78 @@ func reifySliceMerge(
 	return reifyDoArray(opts, tmp, tTo.Elem(), start, val, arr)
 }
 
+// reifySliceMergeByKey merges the config array elements into the elements of
+// old with the same value in the key field. Elements without matching key are
+// appended.
+func reifySliceMergeByKey(
+	opts fieldOptions,
+	old reflect.Value,
+	tTo reflect.Type,
+	val value,
+	arr []value,
+) (reflect.Value, Error) {
+	key := opts.mergeKey()
+	to := reflect.MakeSlice(tTo, old.Len(), old.Len()+len(arr))
+	reflect.Copy(to, old)
+
+	index := map[string]int{}
+	for i := 0; i < to.Len(); i++ {
+		elem := chaseValue(to.Index(i))
+		if elem.Kind() == reflect.Ptr || elem.Kind() == reflect.Interface {
+			// nil value
+			continue
+		}
+
+		cfg, err := normalize(opts.opts, elem.Interface())
+		if err != nil {
+			continue
+		}
+		if k, ok := arrElemKey(opts.opts, key, cfgSub{cfg}); ok {
+			if _, exists := index[k]; !exists {
+				index[k] = i
+			}
+		}
+	}
+
+	// the key only applies to the array itself, not to nested arrays
+	elemOpts := opts
+	elemOpts.tag = noTagOpts
+	elemOpts.opts = &options{}
+	*elemOpts.opts = *opts.opts
+	elemOpts.opts.configValueHandling = cfgDefaultHandling
+	elemOpts.opts.mergeKey = ""
+
+	for _, v := range arr {
+		k, ok := arrElemKey(opts.opts, key, v)
+		i, exists := index[k]
+		if !ok || !exists {
+			i = to.Len()
+			if ok {
+				index[k] = i
+			}
+			to = reflect.Append(to, reflect.Zero(tTo.Elem()))
+		}
+
+		merged, err := reifyMergeValue(elemOpts, to.Index(i), v)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if merged.IsValid() {
+			to.Index(i).Set(merged)
+		}
+	}
+
+	if !opts.opts.noValidate {
+		if err := runValidators(to.Interface(), opts.validators); err != nil {
+			return reflect.Value{}, raiseValidation(val.Context(), val.meta(), "", err)
+		}
+		if err := tryValidate(to); err != nil {
+			return reflect.Value{}, raiseValidation(val.Context(), val.meta(), "", err)
+		}
+	}
+	return to, nil
+}
+
 func reifyDoArray(
 	opts fieldOptions,
 	to reflect.Value, elemT reflect.Type,
@@ -684,7 +924,7 @@ func castArr(opts *options, v value) ([]value, Error) {
 	l, err := v.Len(opts)
 	if err != nil {
 		ctx := v.Context()
-		return nil, raisePathErr(err, v.meta(), "", ctx.path("."))
+		return nil, raisePathErr(err, v.meta(), "", ctx.structuredPath())
 	}
 
 	if l == 0 {
@@ -734,22 +974,66 @@ func reifyPrimitive(
 	return pointerize(t, baseType, chaseValuePointers(v)), nil
 }
 
+// reifyExtras holds the special types unpacked from primitive values.
+var reifyExtras = map[reflect.Type]func(fieldOptions, value, reflect.Type) (reflect.Value, Error){
+	tDuration:    reifyDuration,
+	tRegexp:      reifyRegexp,
+	tURL:         reifyURL,
+	tIP:          reifyIP,
+	tIPNet:       reifyIPNet,
+	tNetipAddr:   reifyNetipAddr,
+	tNetipPrefix: reifyNetipPrefix,
+	tTime:        reifyTime,
+	tLocation:    reifyLocation,
+	tFileMode:    reifyFileMode,
+	tByteSize:    reifyByteSize,
+}
+
+// isPrimitiveType checks if values of type t are unpacked from primitive
+// settings, even if the kind of t is a struct or slice.
+func isPrimitiveType(t reflect.Type) bool {
+	return reifyExtras[t] != nil || isTextUnmarshaler(t) || isJSONUnmarshaler(t)
+}
+
+// isPrimitiveValue checks if val is no object or array.
+func isPrimitiveValue(opts *options, val value) bool {
+	switch val.(type) {
+	case nil, cfgSub, *cfgNil:
+		return false
+	case *cfgBool, *cfgInt, *cfgUint, *cfgFloat, *cfgString:
+		return true
+	}
+
+	// resolving dynamic values must not mark references as active
+	previous := opts.activeFields
+	opts.activeFields = newFieldSet(previous)
+	defer func() { opts.activeFields = previous }()
+
+	_, err := val.toConfig(opts)
+	return err != nil
+}
+
+func isTextUnmarshaler(t reflect.Type) bool {
+	return t.Kind() != reflect.Interface && reflect.PtrTo(t).Implements(tTextUnmarshaler)
+}
+
+func isJSONUnmarshaler(t reflect.Type) bool {
+	return t.Kind() != reflect.Interface && reflect.PtrTo(t).Implements(tJSONUnmarshaler)
+}
+
 func doReifyPrimitive(
 	opts fieldOptions,
 	val value,
 	baseType reflect.Type,
 ) (reflect.Value, Error) {
-	extras := map[reflect.Type]func(fieldOptions, value, reflect.Type) (reflect.Value, Error){
-		tDuration: reifyDuration,
-		tRegexp:   reifyRegexp,
-	}
+	extras := reifyExtras
 
 	previous := opts.opts.activeFields
 	opts.opts.activeFields = newFieldSet(previous)
 	valT, err := val.typ(opts.opts)
 	if err != nil {
 		ctx := val.Context()
-		return reflect.Value{}, raisePathErr(err, val.meta(), "", ctx.path("."))
+		return reflect.Value{}, raisePathErr(err, val.meta(), "", ctx.structuredPath())
 	}
 	opts.opts.activeFields = previous
 
@@ -760,24 +1044,38 @@ func doReifyPrimitive(
 		v, err := val.reflect(opts.opts)
 		if err != nil {
 			ctx := val.Context()
-			return reflect.Value{}, raisePathErr(err, val.meta(), "", ctx.path("."))
+			return reflect.Value{}, raisePathErr(err, val.meta(), "", ctx.structuredPath())
 		}
 		return v, nil
 
-	case kind == reflect.String:
-		s, err := val.toString(opts.opts)
+	case extras[baseType] != nil:
+		v, err := extras[baseType](opts, val, baseType)
 		if err != nil {
-			return reflect.Value{}, raiseConversion(opts.opts, val, err, "string")
+			return v, err
 		}
-		return reflect.ValueOf(s).Convert(baseType), nil
+		return v, nil
 
-	case extras[baseType] != nil:
-		v, err := extras[baseType](opts, val, baseType)
+	case isTextUnmarshaler(baseType) && (valT.gotype == tString || !isNumberOrBool(kind)):
+		v, err := reifyTextUnmarshaler(opts, val, baseType)
 		if err != nil {
 			return v, err
 		}
 		return v, nil
 
+	case isJSONUnmarshaler(baseType) && (valT.gotype == tString || !isNumberOrBool(kind)):
+		v, err := reifyJSONUnmarshaler(opts, val, baseType)
+		if err != nil {
+			return v, err
+		}
+		return v, nil
+
+	case kind == reflect.String:
+		s, err := val.toString(opts.opts)
+		if err != nil {
+			return reflect.Value{}, raiseConversion(opts.opts, val, err, "string")
+		}
+		return reflect.ValueOf(s).Convert(baseType), nil
+
 	case isInt(kind):
 		v, err := reifyInt(opts, val, baseType)
 		if err != nil {
@@ -810,7 +1108,7 @@ func doReifyPrimitive(
 		v, err := val.reflect(opts.opts)
 		if err != nil {
 			ctx := val.Context()
-			return reflect.Value{}, raisePathErr(err, val.meta(), "", ctx.path("."))
+			return reflect.Value{}, raisePathErr(err, val.meta(), "", ctx.structuredPath())
 		}
 		return v.Convert(baseType), nil
 	}
@@ -868,6 +1166,224 @@ func reifyRegexp(
 	return reflect.ValueOf(r).Elem(), nil
 }
 
+func reifyURL(
+	opts fieldOptions,
+	val value,
+	_ reflect.Type,
+) (reflect.Value, Error) {
+	s, err := val.toString(opts.opts)
+	if err != nil {
+		return reflect.Value{}, raiseConversion(opts.opts, val, err, "url")
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return reflect.Value{}, raiseInvalidURL(val, err)
+	}
+	return reflect.ValueOf(u).Elem(), nil
+}
+
+func reifyIP(
+	opts fieldOptions,
+	val value,
+	_ reflect.Type,
+) (reflect.Value, Error) {
+	s, err := val.toString(opts.opts)
+	if err != nil {
+		return reflect.Value{}, raiseConversion(opts.opts, val, err, "ip")
+	}
+
+	ip := net.ParseIP(strings.TrimSpace(s))
+	if ip == nil {
+		return reflect.Value{}, raiseInvalidIP(val, &net.ParseError{Type: "IP address", Text: s})
+	}
+	return reflect.ValueOf(ip), nil
+}
+
+func reifyNetipAddr(
+	opts fieldOptions,
+	val value,
+	_ reflect.Type,
+) (reflect.Value, Error) {
+	s, err := val.toString(opts.opts)
+	if err != nil {
+		return reflect.Value{}, raiseConversion(opts.opts, val, err, "ip")
+	}
+
+	addr, err := netip.ParseAddr(strings.TrimSpace(s))
+	if err != nil {
+		return reflect.Value{}, raiseInvalidIP(val, err)
+	}
+	return reflect.ValueOf(addr), nil
+}
+
+func reifyIPNet(
+	opts fieldOptions,
+	val value,
+	_ reflect.Type,
+) (reflect.Value, Error) {
+	s, err := val.toString(opts.opts)
+	if err != nil {
+		return reflect.Value{}, raiseConversion(opts.opts, val, err, "cidr")
+	}
+
+	_, ipNet, err := net.ParseCIDR(strings.TrimSpace(s))
+	if err != nil {
+		return reflect.Value{}, raiseInvalidCIDR(val, err)
+	}
+	return reflect.ValueOf(ipNet).Elem(), nil
+}
+
+func reifyNetipPrefix(
+	opts fieldOptions,
+	val value,
+	_ reflect.Type,
+) (reflect.Value, Error) {
+	s, err := val.toString(opts.opts)
+	if err != nil {
+		return reflect.Value{}, raiseConversion(opts.opts, val, err, "cidr")
+	}
+
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(s))
+	if err != nil {
+		return reflect.Value{}, raiseInvalidCIDR(val, err)
+	}
+	return reflect.ValueOf(prefix), nil
+}
+
+func reifyTime(
+	opts fieldOptions,
+	val value,
+	_ reflect.Type,
+) (reflect.Value, Error) {
+	s, err := val.toString(opts.opts)
+	if err != nil {
+		return reflect.Value{}, raiseConversion(opts.opts, val, err, "time")
+	}
+
+	ts, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(s))
+	if err != nil {
+		return reflect.Value{}, raiseInvalidTime(val, err)
+	}
+	return reflect.ValueOf(ts), nil
+}
+
+func reifyLocation(
+	opts fieldOptions,
+	val value,
+	_ reflect.Type,
+) (reflect.Value, Error) {
+	s, err := val.toString(opts.opts)
+	if err != nil {
+		return reflect.Value{}, raiseConversion(opts.opts, val, err, "location")
+	}
+
+	loc, err := time.LoadLocation(strings.TrimSpace(s))
+	if err != nil {
+		return reflect.Value{}, raiseInvalidLocation(val, err)
+	}
+	return reflect.ValueOf(loc).Elem(), nil
+}
+
+func reifyFileMode(
+	opts fieldOptions,
+	val value,
+	_ reflect.Type,
+) (reflect.Value, Error) {
+	var mode uint64
+	var err error
+
+	switch v := val.(type) {
+	case *cfgInt, *cfgUint:
+		// numbers are already decoded by the config format (e.g. YAML 0644)
+		mode, err = v.toUint(opts.opts)
+	default:
+		var s string
+		if s, err = val.toString(opts.opts); err == nil {
+			mode, err = strconv.ParseUint(strings.TrimSpace(s), 8, 32)
+		}
+	}
+
+	if err == nil && mode > math.MaxUint32 {
+		err = ErrOverflow
+	}
+	if err != nil {
+		return reflect.Value{}, raiseInvalidFileMode(val, err)
+	}
+	return reflect.ValueOf(os.FileMode(mode)), nil
+}
+
+func reifyByteSize(
+	opts fieldOptions,
+	val value,
+	_ reflect.Type,
+) (reflect.Value, Error) {
+	var size ByteSize
+	var err error
+
+	switch v := val.(type) {
+	case *cfgInt, *cfgUint:
+		var u uint64
+		u, err = v.toUint(opts.opts)
+		size = ByteSize(u)
+	case *cfgFloat:
+		if math.IsNaN(v.f) || v.f < 0 || v.f >= math.MaxUint64 {
+			err = ErrOverflow
+		}
+		size = ByteSize(math.Round(v.f))
+	default:
+		var s string
+		if s, err = val.toString(opts.opts); err == nil {
+			size, err = ParseByteSize(s)
+		}
+	}
+
+	if err != nil {
+		return reflect.Value{}, raiseInvalidByteSize(val, err)
+	}
+	return reflect.ValueOf(size), nil
+}
+
+func reifyTextUnmarshaler(
+	opts fieldOptions,
+	val value,
+	t reflect.Type,
+) (reflect.Value, Error) {
+	s, err := val.toString(opts.opts)
+	if err != nil {
+		return reflect.Value{}, raiseConversion(opts.opts, val, err, t.String())
+	}
+
+	v := reflect.New(t)
+	if err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+		return reflect.Value{}, raiseUnmarshal(val, t, err)
+	}
+	return v.Elem(), nil
+}
+
+func reifyJSONUnmarshaler(
+	opts fieldOptions,
+	val value,
+	t reflect.Type,
+) (reflect.Value, Error) {
+	reified, err := val.reify(opts.opts)
+	if err != nil {
+		ctx := val.Context()
+		return reflect.Value{}, raisePathErr(err, val.meta(), "", ctx.structuredPath())
+	}
+
+	raw, err := json.Marshal(reified)
+	if err != nil {
+		return reflect.Value{}, raiseUnmarshal(val, t, err)
+	}
+
+	v := reflect.New(t)
+	if err := v.Interface().(json.Unmarshaler).UnmarshalJSON(raw); err != nil {
+		return reflect.Value{}, raiseUnmarshal(val, t, err)
+	}
+	return v.Elem(), nil
+}
+
 func reifyInt(
 	opts fieldOptions,
 	val value,
@@ -18,7 +18,18 @@
 package ucfg
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/elastic/go-ucfg/parse"
 	"github.com/stretchr/testify/assert"
@@ -1219,6 +1230,287 @@ func TestUnpackCustomPrimitiveTypes(t *testing.T) {
 	assert.Equal(t, CustomString("hello"), out.S)
 }
 
+type testLevel int
+
+const (
+	testLevelDebug testLevel = iota - 1
+	testLevelInfo
+	testLevelWarn
+)
+
+func (l *testLevel) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "debug":
+		*l = testLevelDebug
+	case "info":
+		*l = testLevelInfo
+	case "warn":
+		*l = testLevelWarn
+	default:
+		return fmt.Errorf("unknown level '%s'", text)
+	}
+	return nil
+}
+
+func (l testLevel) MarshalText() ([]byte, error) {
+	return []byte([...]string{"debug", "info", "warn"}[l+1]), nil
+}
+
+type testJSONPoint struct {
+	X, Y int
+}
+
+func (p *testJSONPoint) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	_, err := fmt.Sscanf(s, "%d,%d", &p.X, &p.Y)
+	return err
+}
+
+func TestUnpackTextUnmarshaler(t *testing.T) {
+	c, err := NewFrom(map[string]interface{}{
+		"ip":      "192.168.1.1",
+		"addr":    "::1",
+		"prefix":  "10.0.0.0/8",
+		"big":     "123456789012345678901234567890",
+		"bignum":  42,
+		"level":   "WARN",
+		"levelno": -1,
+		"levels":  []string{"info", "debug"},
+		"point":   "1,2",
+	})
+	require.NoError(t, err)
+
+	var settings struct {
+		IP      net.IP
+		Addr    *netip.Addr
+		Prefix  netip.Prefix
+		Big     *big.Int
+		BigNum  big.Int
+		Level   testLevel
+		LevelNo testLevel
+		Levels  []testLevel
+		Point   testJSONPoint
+	}
+	settings.Level = testLevelInfo
+	require.NoError(t, c.Unpack(&settings))
+
+	expectedBig, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	assert.Equal(t, net.ParseIP("192.168.1.1"), settings.IP)
+	assert.Equal(t, netip.MustParseAddr("::1"), *settings.Addr)
+	assert.Equal(t, netip.MustParsePrefix("10.0.0.0/8"), settings.Prefix)
+	assert.Equal(t, expectedBig, settings.Big)
+	assert.Equal(t, int64(42), settings.BigNum.Int64())
+	assert.Equal(t, testLevelWarn, settings.Level)
+	assert.Equal(t, testLevelDebug, settings.LevelNo)
+	assert.Equal(t, []testLevel{testLevelInfo, testLevelDebug}, settings.Levels)
+	assert.Equal(t, testJSONPoint{1, 2}, settings.Point)
+}
+
+func TestUnpackTextUnmarshalerFail(t *testing.T) {
+	tests := map[string]interface{}{
+		"ip":    &struct{ IP net.IP }{},
+		"level": &struct{ Level testLevel }{},
+		"point": &struct{ Point testJSONPoint }{},
+	}
+
+	c, err := NewFrom(map[string]interface{}{
+		"ip":    "not an ip",
+		"level": "fatal",
+		"point": "x",
+	})
+	require.NoError(t, err)
+
+	for name, to := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := c.Unpack(to)
+			require.Error(t, err)
+			assert.Equal(t, name, err.(Error).Path())
+			t.Log(err)
+		})
+	}
+}
+
+func TestTextMarshalerRoundTrip(t *testing.T) {
+	type settings struct {
+		IP     net.IP
+		Prefix netip.Prefix
+		Big    *big.Int
+		Level  testLevel
+		Levels []testLevel
+	}
+
+	in := settings{
+		IP:     net.ParseIP("10.1.2.3"),
+		Prefix: netip.MustParsePrefix("fd00::/8"),
+		Big:    big.NewInt(1234),
+		Level:  testLevelWarn,
+		Levels: []testLevel{testLevelDebug},
+	}
+
+	c, err := NewFrom(in, MarshalText)
+	require.NoError(t, err)
+
+	level, err := c.String("level", -1)
+	require.NoError(t, err)
+	assert.Equal(t, "warn", level)
+
+	ip, err := c.String("ip", -1)
+	require.NoError(t, err)
+	assert.Equal(t, "10.1.2.3", ip)
+
+	var out settings
+	require.NoError(t, c.Unpack(&out))
+	assert.Equal(t, in, out)
+
+	// without MarshalText only the special types are encoded as strings
+	c, err = NewFrom(in)
+	require.NoError(t, err)
+
+	levelNo, err := c.Int("level", -1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(testLevelWarn), levelNo)
+
+	ip, err = c.String("ip", -1)
+	require.NoError(t, err)
+	assert.Equal(t, "10.1.2.3", ip)
+
+	prefix, err := c.String("prefix", -1)
+	require.NoError(t, err)
+	assert.Equal(t, "fd00::/8", prefix)
+}
+
+func TestUnpackSpecialTypes(t *testing.T) {
+	c, err := NewFrom(map[string]interface{}{
+		"url":      "https://user@example.com:9200/path?q=1",
+		"ip":       "::ffff:10.0.0.1",
+		"addr":     "10.0.0.1",
+		"net":      "192.168.1.12/24",
+		"prefix":   "fd00::/8",
+		"time":     "2024-03-01T12:30:00Z",
+		"location": "Europe/Berlin",
+		"mode":     "0640",
+		"modenum":  0755,
+		"size":     "10MiB",
+		"sizenum":  2048,
+		"sizes":    []interface{}{"1KB", 1},
+	})
+	require.NoError(t, err)
+
+	var settings struct {
+		URL      *url.URL
+		URLValue url.URL `config:"url"`
+		IP       net.IP
+		Addr     netip.Addr
+		Net      net.IPNet
+		Prefix   *netip.Prefix
+		Time     time.Time
+		Location *time.Location
+		Mode     os.FileMode
+		ModeNum  os.FileMode
+		Size     ByteSize
+		SizeNum  *ByteSize
+		Sizes    []ByteSize
+	}
+	require.NoError(t, c.Unpack(&settings))
+
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	_, expectedNet, _ := net.ParseCIDR("192.168.1.0/24")
+	assert.Equal(t, "example.com:9200", settings.URL.Host)
+	assert.Equal(t, "/path", settings.URLValue.Path)
+	assert.Equal(t, net.ParseIP("10.0.0.1"), settings.IP)
+	assert.Equal(t, netip.MustParseAddr("10.0.0.1"), settings.Addr)
+	assert.Equal(t, *expectedNet, settings.Net)
+	assert.Equal(t, netip.MustParsePrefix("fd00::/8"), *settings.Prefix)
+	assert.Equal(t, time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC), settings.Time)
+	assert.Equal(t, berlin.String(), settings.Location.String())
+	assert.Equal(t, os.FileMode(0640), settings.Mode)
+	assert.Equal(t, os.FileMode(0755), settings.ModeNum)
+	assert.Equal(t, 10*MiB, settings.Size)
+	assert.Equal(t, ByteSize(2048), *settings.SizeNum)
+	assert.Equal(t, []ByteSize{KB, B}, settings.Sizes)
+}
+
+func TestUnpackSpecialTypesFail(t *testing.T) {
+	tests := map[string]struct {
+		value   interface{}
+		to      interface{}
+		message string
+		reason  error
+	}{
+		"url":      {"http://[::1", &struct{ X url.URL }{}, "invalid URL", ErrInvalidURL},
+		"ip":       {"10.0.0", &struct{ X net.IP }{}, "invalid IP address", ErrInvalidIP},
+		"addr":     {"::g", &struct{ X netip.Addr }{}, "invalid IP address", ErrInvalidIP},
+		"ipnet":    {"10.0.0.0", &struct{ X net.IPNet }{}, "invalid CIDR", ErrInvalidCIDR},
+		"prefix":   {"10.0.0.0/33", &struct{ X netip.Prefix }{}, "invalid CIDR", ErrInvalidCIDR},
+		"time":     {"yesterday", &struct{ X time.Time }{}, "invalid RFC3339 timestamp", ErrInvalidTime},
+		"location": {"Mars/Olympus", &struct{ X *time.Location }{}, "invalid time zone location", ErrInvalidLocation},
+		"mode":     {"0999", &struct{ X os.FileMode }{}, "invalid file mode", ErrInvalidFileMode},
+		"size":     {"10 parsecs", &struct{ X ByteSize }{}, "invalid byte size", ErrInvalidByteSize},
+		"negative": {-1, &struct{ X ByteSize }{}, "invalid byte size", ErrInvalidByteSize},
+		"nan":      {math.NaN(), &struct{ X ByteSize }{}, "invalid byte size", ErrInvalidByteSize},
+		"overflow": {1e30, &struct{ X ByteSize }{}, "invalid byte size", ErrOverflow},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, err := NewFrom(map[string]interface{}{"x": test.value}, MetaData(Meta{Source: "test.yml"}))
+			require.NoError(t, err)
+
+			err = c.Unpack(test.to)
+			require.Error(t, err)
+			assert.Equal(t, "x", err.(Error).Path())
+			assert.Contains(t, err.Error(), test.message)
+			assert.Contains(t, err.Error(), "source:'test.yml'")
+			assert.True(t, errors.Is(err, test.reason), "errors.Is(%v, %v) = false", err, test.reason)
+		})
+	}
+}
+
+func TestSpecialTypesRoundTrip(t *testing.T) {
+	type settings struct {
+		URL      *url.URL
+		IP       net.IP
+		Net      net.IPNet
+		Time     time.Time
+		Location *time.Location
+		Mode     os.FileMode
+		Size     ByteSize
+	}
+
+	u, _ := url.Parse("http://localhost:5601/app")
+	_, n, _ := net.ParseCIDR("10.0.0.0/8")
+	in := settings{
+		URL:      u,
+		IP:       net.ParseIP("127.0.0.1"),
+		Net:      *n,
+		Time:     time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC),
+		Location: time.UTC,
+		Mode:     0600,
+		Size:     512 * KiB,
+	}
+
+	c, err := NewFrom(in)
+	require.NoError(t, err)
+
+	for name, expected := range map[string]string{
+		"mode": "0600",
+		"size": "512KiB",
+		"net":  "10.0.0.0/8",
+		"url":  "http://localhost:5601/app",
+	} {
+		s, err := c.String(name, -1)
+		require.NoError(t, err)
+		assert.Equal(t, expected, s)
+	}
+
+	var out settings
+	require.NoError(t, c.Unpack(&out))
+	assert.Equal(t, in, out)
+}
+
 func assertConfig(t *testing.T, config *Config, expected interface{}) {
 	var actual interface{}
 
@@ -149,6 +149,58 @@ func (c *context) path(sep string) string {
 	return c.field
 }
 
+// quotedPath returns the '.' separated path of c, quoting names containing '.'
+// or brackets, such that the path can be parsed by ParsePath.
+func (c *context) quotedPath() string {
+	p := c.structuredPath()
+	return p.String(".")
+}
+
+// structuredPath returns the path of c. Numeric fields of array elements are
+// reported as indices.
+func (c *context) structuredPath() Path {
+	if c.field == "" {
+		return Path{}
+	}
+
+	var parent Path
+	if c.parent != nil {
+		p := c.parent.Context()
+		parent = p.structuredPath()
+	}
+
+	if c.isIndex() {
+		i, _ := strconv.Atoi(c.field)
+		return parent.Index(i)
+	}
+	return parent.Append(c.field)
+}
+
+// structuredPathOf appends the field name to the path of c.
+func (c *context) structuredPathOf(field string) Path {
+	p := c.structuredPath()
+	return p.Append(field)
+}
+
+func (c *context) isIndex() bool {
+	if _, err := strconv.Atoi(c.field); err != nil {
+		return false
+	}
+	if cfg := c.getParent(); cfg != nil {
+		_, named := cfg.fields.get(c.field)
+		return !named
+	}
+	return true
+}
+
+// quotedPathOf appends the formatted path of a field to the quoted path of c.
+func (c *context) quotedPathOf(field string) string {
+	if p := c.quotedPath(); p != "" {
+		return fmt.Sprintf("%v.%v", p, field)
+	}
+	return field
+}
+
 func (c *context) pathOf(field, sep string) string {
 	if p := c.path(sep); p != "" {
 		return fmt.Sprintf("%v%v%v", p, sep, field)
@@ -333,8 +385,10 @@ func (c cfgSub) cpy(ctx context) value {
 	dict := c.c.fields.dict()
 	arr := c.c.fields.array()
 	fields := &fields{}
+	fields.reserve(len(dict))
 
-	for name, f := range dict {
+	for _, name := range c.c.fields.dictKeys() {
+		f := dict[name]
 		ctx := f.Context()
 		v := f.cpy(context{field: ctx.field, parent: newC})
 		fields.set(name, v)
@@ -364,6 +418,15 @@ func (c cfgSub) SetContext(ctx context) {
 	}
 }
 
+// relocate sets the context of v after v has been moved to a new position.
+// Unlike SetContext, the context of a sub-configuration is replaced as well.
+func relocate(v value, ctx context) {
+	if sub, ok := v.(cfgSub); ok {
+		sub.c.ctx = context{}
+	}
+	v.SetContext(ctx)
+}
+
 func (c cfgSub) reify(opts *options) (interface{}, error) {
 	parentFields := opts.activeFields
 	defer func() { opts.activeFields = parentFields }()
@@ -18,7 +18,13 @@
 package ucfg
 
 import (
+	"encoding"
+	"encoding/json"
 	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
 	"reflect"
 	"regexp"
 	"sort"
@@ -49,8 +55,23 @@ type fieldOptions struct {
 type fields struct {
 	d map[string]value
 	a []value
+
+	// keys of d in insertion order. Removed keys are only removed from keys
+	// on the next access, stale counts the removed keys still in keys.
+	keys  []string
+	stale int
+}
+
+// MapItem is a key-value pair in a MapSlice.
+type MapItem struct {
+	Key   string
+	Value interface{}
 }
 
+// MapSlice is an ordered map. Merge and NewFrom keep the order of the keys in
+// a MapSlice, while the keys of Go maps are added in sorted order.
+type MapSlice []MapItem
+
 // Meta holds additional meta data per config value.
 type Meta struct {
 	Source string
@@ -60,6 +81,7 @@ var (
 	tConfig         = reflect.TypeOf(Config{})
 	tConfigPtr      = reflect.PtrTo(tConfig)
 	tConfigMap      = reflect.TypeOf((map[string]interface{})(nil))
+	tMapSlice       = reflect.TypeOf(MapSlice(nil))
 	tInterfaceArray = reflect.TypeOf([]interface{}(nil))
 
 	// interface types
@@ -67,6 +89,10 @@ var (
 	iInitializer = reflect.TypeOf((*Initializer)(nil)).Elem()
 	tValidator   = reflect.TypeOf((*Validator)(nil)).Elem()
 
+	tTextMarshaler   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	tTextUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	tJSONUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
 	// primitives
 	tBool     = reflect.TypeOf(true)
 	tInt64    = reflect.TypeOf(int64(0))
@@ -75,19 +101,30 @@ var (
 	tString   = reflect.TypeOf("")
 	tDuration = reflect.TypeOf(time.Duration(0))
 	tRegexp   = reflect.TypeOf(regexp.Regexp{})
+
+	// special types
+	tURL         = reflect.TypeOf(url.URL{})
+	tIP          = reflect.TypeOf(net.IP{})
+	tIPNet       = reflect.TypeOf(net.IPNet{})
+	tNetipAddr   = reflect.TypeOf(netip.Addr{})
+	tNetipPrefix = reflect.TypeOf(netip.Prefix{})
+	tTime        = reflect.TypeOf(time.Time{})
+	tLocation    = reflect.TypeOf(time.Location{})
+	tFileMode    = reflect.TypeOf(os.FileMode(0))
+	tByteSize    = reflect.TypeOf(ByteSize(0))
 )
 
 // New creates a new empty Config object.
 func New() *Config {
 	return &Config{
-		fields: &fields{nil, nil},
+		fields: &fields{},
 	}
 }
 
 // MustNewFrom creates a new config object normalizing and copying from into the new
 // Config object. MustNewFrom uses Merge to copy from.
 //
-// MustNewFrom supports the options: PathSep, MetaData, StructTag, VarExp
+// MustNewFrom supports the options: PathSep, MetaData, StructTag, VarExp, MarshalText
 func MustNewFrom(from interface{}, opts ...Option) *Config {
 	c := New()
 	if err := c.Merge(from, opts...); err != nil {
@@ -99,7 +136,7 @@ func MustNewFrom(from interface{}, opts ...Option) *Config {
 // NewFrom creates a new config object normalizing and copying from into the new
 // Config object. NewFrom uses Merge to copy from.
 //
-// NewFrom supports the options: PathSep, MetaData, StructTag, VarExp
+// NewFrom supports the options: PathSep, MetaData, StructTag, VarExp, MarshalText
 func NewFrom(from interface{}, opts ...Option) (*Config, error) {
 	c := New()
 	if err := c.Merge(from, opts...); err != nil {
@@ -118,12 +155,16 @@ func (c *Config) IsArray() bool {
 	return c.fields.array() != nil
 }
 
-// GetFields returns a list of all top-level named keys in c.
+// GetFields returns a list of all top-level named keys in c. The keys are
+// returned in the order they have been added to c.
 func (c *Config) GetFields() []string {
-	var names []string
-	for k := range c.fields.dict() {
-		names = append(names, k)
+	keys := c.fields.dictKeys()
+	if len(keys) == 0 {
+		return nil
 	}
+
+	names := make([]string, len(keys))
+	copy(names, keys)
 	return names
 }
 
@@ -136,6 +177,12 @@ func (c *Config) Has(name string, idx int, options ...Option) (bool, error) {
 	return p.Has(c, opts)
 }
 
+// HasAt checks if a setting exists at the path p like Has. The empty path
+// always exists.
+func (c *Config) HasAt(p Path, options ...Option) (bool, error) {
+	return p.cfgPath(".").Has(c, makeOptions(options))
+}
+
 // HasField checks if c has a top-level named key name.
 func (c *Config) HasField(name string) bool {
 	_, ok := c.fields.get(name)
@@ -171,6 +218,93 @@ func (c *Config) Remove(name string, idx int, options ...Option) (bool, error) {
 	return p.Remove(c, opts)
 }
 
+// RemoveAt removes the setting at the path p like Remove. The empty path can
+// not be removed.
+func (c *Config) RemoveAt(p Path, options ...Option) (bool, error) {
+	if len(p.fields) == 0 {
+		return false, nil
+	}
+
+	opts := makeOptions(options)
+	opts.env = nil
+	opts.resolvers = nil
+	opts.noParse = true
+	return p.cfgPath(".").Remove(c, opts)
+}
+
+// Move moves the setting at the path from to the path to, replacing any
+// existing setting at to. The setting keeps its meta data, and variables are
+// moved unresolved. Array elements following a moved array element are
+// shifted. Move returns an error with reason ErrMissing if from does not
+// exist. If the setting can not be stored at to, the setting is restored at
+// its old position.
+//
+// Move supports the options: PathSep
+func (c *Config) Move(from, to string, options ...Option) error {
+	opts := makeOptions(options)
+
+	// ignore environments
+	opts.env = nil
+	opts.resolvers = nil
+	opts.noParse = true
+
+	src := parsePathWithOpts(from, opts)
+	dst := parsePathWithOpts(to, opts)
+	v, err := c.getPath(src, opts)
+	if err != nil {
+		return err
+	}
+	if src.String() == dst.String() {
+		return nil
+	}
+
+	// keep a copy of the settings holding the source, such that the removed
+	// setting can be restored at its old position if the move fails.
+	container, err := c.getPath(cfgPath{fields: src.fields[:len(src.fields)-1], sep: src.sep}, opts)
+	if err != nil {
+		return err
+	}
+	parent, cfgErr := container.toConfig(opts)
+	if cfgErr != nil {
+		return cfgErr
+	}
+	saved := parent.fields.snapshot()
+
+	if _, err := src.Remove(c, opts); err != nil {
+		return err
+	}
+
+	relocate(v, context{})
+	if err := dst.SetValue(c, opts, v); err != nil {
+		*parent.fields = saved
+		parent.fields.relocateAll(cfgSub{parent})
+		return err
+	}
+	return nil
+}
+
+// Copy copies the setting at the path from to the path to, replacing any
+// existing setting at to. The copy keeps the meta data of all settings, and
+// variables are copied unresolved, being resolved relative to the new
+// position. Copy returns an error with reason ErrMissing if from does not
+// exist.
+//
+// Copy supports the options: PathSep
+func (c *Config) Copy(from, to string, options ...Option) error {
+	opts := makeOptions(options)
+
+	// ignore environments
+	opts.env = nil
+	opts.resolvers = nil
+	opts.noParse = true
+
+	v, err := c.getPath(parsePathWithOpts(from, opts), opts)
+	if err != nil {
+		return err
+	}
+	return parsePathWithOpts(to, opts).SetValue(c, opts, v.cpy(context{}))
+}
+
 // Path gets the absolute path of c separated by sep. If c is a root-Config an
 // empty string will be returned.
 func (c *Config) Path(sep string) string {
@@ -201,23 +335,33 @@ func (c *Config) Parent() *Config {
 	}
 }
 
-// FlattenedKeys return a sorted flattened views of the set keys in the configuration
+// FlattenedKeys return a sorted flattened views of the set keys in the configuration.
+// If PathSep is set, names containing the separator or brackets are quoted
+// (e.g. 'labels["app.kubernetes.io/name"]'), such that the keys can be passed
+// to Get, Set and Remove.
 func (c *Config) FlattenedKeys(opts ...Option) []string {
 	var keys []string
 	normalizedOptions := makeOptions(opts)
 
-	if normalizedOptions.pathSep == "" {
+	quote := normalizedOptions.pathSep != ""
+	if !quote {
 		normalizedOptions.pathSep = "."
 	}
+	keyPath := func(v value) string {
+		ctx := v.Context()
+		if !quote {
+			return ctx.path(normalizedOptions.pathSep)
+		}
+		p := ctx.structuredPath()
+		return p.String(normalizedOptions.pathSep)
+	}
 
 	if c.IsDict() {
 		for _, v := range c.fields.dict() {
 
 			subcfg, err := v.toConfig(normalizedOptions)
 			if err != nil {
-				ctx := v.Context()
-				p := ctx.path(normalizedOptions.pathSep)
-				keys = append(keys, p)
+				keys = append(keys, keyPath(v))
 			} else {
 				newKeys := subcfg.FlattenedKeys(opts...)
 				keys = append(keys, newKeys...)
@@ -228,9 +372,7 @@ func (c *Config) FlattenedKeys(opts ...Option) []string {
 			scfg, err := a.toConfig(normalizedOptions)
 
 			if err != nil {
-				ctx := a.Context()
-				p := ctx.path(normalizedOptions.pathSep)
-				keys = append(keys, p)
+				keys = append(keys, keyPath(a))
 			} else {
 				newKeys := scfg.FlattenedKeys(opts...)
 				keys = append(keys, newKeys...)
@@ -254,6 +396,40 @@ func (f *fields) dict() map[string]value {
 	return f.d
 }
 
+// dictKeys returns the keys of dict() in insertion order. dictKeys does not
+// modify f, such that concurrent reads remain safe.
+func (f *fields) dictKeys() []string {
+	if f.stale == 0 {
+		return f.keys
+	}
+	return f.liveKeys()
+}
+
+// reserve preallocates space for n named fields.
+func (f *fields) reserve(n int) {
+	if f.d == nil && n > 0 {
+		f.d = make(map[string]value, n)
+		f.keys = make([]string, 0, n)
+	}
+}
+
+// compact drops removed keys from the ordered list of keys.
+func (f *fields) compact() {
+	if f.stale > 0 {
+		f.keys, f.stale = f.liveKeys(), 0
+	}
+}
+
+func (f *fields) liveKeys() []string {
+	keys := make([]string, 0, len(f.d))
+	for _, k := range f.keys {
+		if _, exists := f.d[k]; exists {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
 func (f *fields) array() []value {
 	return f.a
 }
@@ -262,29 +438,53 @@ func (f *fields) del(name string) bool {
 	_, exists := f.d[name]
 	if exists {
 		delete(f.d, name)
+		f.stale++
+		if f.stale > len(f.d) {
+			f.compact()
+		}
 	}
 	return exists
 }
 
-func (f *fields) delAt(i int) bool {
-	a := f.a
-	if i < 0 || len(a) <= i {
-		return false
-	}
-
-	copy(a[i:], a[i+1:])
-	a[len(a)-1] = nil
-	f.a = a[:len(a)-1]
-	return true
-}
-
 func (f *fields) set(name string, v value) {
 	if f.d == nil {
 		f.d = map[string]value{}
 	}
+	if _, exists := f.d[name]; !exists {
+		// drop the old position of removed keys before adding them again
+		f.compact()
+		f.keys = append(f.keys, name)
+	}
 	f.d[name] = v
 }
 
+// snapshot returns a copy of f, such that f can be restored after settings
+// have been added or removed.
+func (f *fields) snapshot() fields {
+	s := fields{keys: append([]string(nil), f.keys...), stale: f.stale}
+	if f.d != nil {
+		s.d = make(map[string]value, len(f.d))
+		for k, v := range f.d {
+			s.d[k] = v
+		}
+	}
+	if f.a != nil {
+		s.a = make([]value, len(f.a))
+		copy(s.a, f.a)
+	}
+	return s
+}
+
+// relocateAll updates the context of all settings in f to the parent.
+func (f *fields) relocateAll(parent value) {
+	for k, v := range f.d {
+		relocate(v, context{parent: parent, field: k})
+	}
+	for i, v := range f.a {
+		relocate(v, context{parent: parent, field: fmt.Sprintf("%d", i)})
+	}
+}
+
 func (f *fields) add(v value) {
 	f.a = append(f.a, v)
 }
@@ -306,6 +506,20 @@ func (f *fields) setAt(idx int, parent, v value) {
 	f.a[idx] = v
 }
 
+// splice replaces the array elements in the range [from, to) with vals,
+// updating the context of all moved elements.
+func (f *fields) splice(from, to int, parent value, vals []value) {
+	a := make([]value, 0, len(f.a)-(to-from)+len(vals))
+	a = append(a, f.a[:from]...)
+	a = append(a, vals...)
+	a = append(a, f.a[to:]...)
+
+	for i := from; i < len(a); i++ {
+		relocate(a[i], context{parent: parent, field: fmt.Sprintf("%d", i)})
+	}
+	f.a = a
+}
+
 func (f *fields) append(parent value, a []value) {
 	l := len(f.a)
 	count := len(a)
@@ -322,6 +536,13 @@ func (f *fields) append(parent value, a []value) {
 	}
 }
 
+func (o *fieldOptions) mergeKey() string {
+	if o.tag.mergeKey != "" {
+		return o.tag.mergeKey
+	}
+	return o.opts.mergeKey
+}
+
 func (o *fieldOptions) configHandling() configHandling {
 	h := o.tag.cfgHandling
 	if h == cfgDefaultHandling {
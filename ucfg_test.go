@@ -18,12 +18,14 @@
 package ucfg
 
 import (
+	"errors"
 	"fmt"
 	"sort"
 	"testing"
 
 	"github.com/elastic/go-ucfg/parse"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var opts = []Option{
@@ -579,3 +581,303 @@ func TestNewFromWithMaxIdxAndEnableNumKeys(t *testing.T) {
 		assert.Equal(t, tc.want, m)
 	}
 }
+
+func TestMove(t *testing.T) {
+	cases := map[string]struct {
+		cfg      map[string]interface{}
+		from, to string
+		wants    map[string]interface{}
+	}{
+		"rename": {
+			cfg:   map[string]interface{}{"a": 1, "b": 2},
+			from:  "a",
+			to:    "c",
+			wants: map[string]interface{}{"b": uint64(2), "c": uint64(1)},
+		},
+		"nested": {
+			cfg:  map[string]interface{}{"output.elasticsearch.ssl.certificate_authorities": []string{"ca.pem"}},
+			from: "output.elasticsearch.ssl.certificate_authorities",
+			to:   "output.elasticsearch.ssl.ca",
+			wants: map[string]interface{}{
+				"output": map[string]interface{}{
+					"elasticsearch": map[string]interface{}{
+						"ssl": map[string]interface{}{"ca": []interface{}{"ca.pem"}},
+					},
+				},
+			},
+		},
+		"replace": {
+			cfg:   map[string]interface{}{"a.x": 1, "b.y": 2},
+			from:  "a",
+			to:    "b",
+			wants: map[string]interface{}{"b": map[string]interface{}{"x": uint64(1)}},
+		},
+		"into own namespace": {
+			cfg:   map[string]interface{}{"a.x": 1},
+			from:  "a",
+			to:    "a.old",
+			wants: map[string]interface{}{"a": map[string]interface{}{"old": map[string]interface{}{"x": uint64(1)}}},
+		},
+		"out of array": {
+			cfg:  map[string]interface{}{"hosts": []string{"a", "b", "c"}},
+			from: "hosts.0",
+			to:   "primary",
+			wants: map[string]interface{}{
+				"hosts":   []interface{}{"b", "c"},
+				"primary": "a",
+			},
+		},
+		"same path": {
+			cfg:   map[string]interface{}{"a": 1},
+			from:  "a",
+			to:    "a",
+			wants: map[string]interface{}{"a": uint64(1)},
+		},
+	}
+
+	for name, test := range cases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			cfg := MustNewFrom(test.cfg, PathSep("."))
+			require.NoError(t, cfg.Move(test.from, test.to, PathSep(".")))
+
+			var actual map[string]interface{}
+			require.NoError(t, cfg.Unpack(&actual))
+			assert.Equal(t, test.wants, actual)
+		})
+	}
+}
+
+func TestMoveKeepsMetaAndContext(t *testing.T) {
+	cfg := MustNewFrom(map[string]interface{}{
+		"old.ssl": map[string]interface{}{"enabled": true, "ca": "${ca}"},
+		"ca":      "ca.pem",
+		"list":    []interface{}{map[string]interface{}{"a": 1}, map[string]interface{}{"b": 2}},
+	}, PathSep("."), VarExp, MetaData(Meta{Source: "beat.yml"}))
+
+	require.NoError(t, cfg.Move("old.ssl", "new.ssl", PathSep(".")))
+
+	has, err := cfg.Has("old.ssl", -1, PathSep("."))
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	meta, err := cfg.MetaOf("new.ssl.enabled", -1, PathSep("."))
+	require.NoError(t, err)
+	assert.Equal(t, "beat.yml", meta.Source)
+
+	sub, err := cfg.Child("new.ssl", -1, PathSep("."))
+	require.NoError(t, err)
+	assert.Equal(t, "new.ssl", sub.Path("."))
+	assert.Equal(t, "new", sub.Parent().Path("."))
+
+	// variables are resolved at the new position
+	ca, err := cfg.String("new.ssl.ca", -1, PathSep("."))
+	require.NoError(t, err)
+	assert.Equal(t, "ca.pem", ca)
+
+	// array elements following a moved element are shifted
+	require.NoError(t, cfg.Move("list.0", "first", PathSep(".")))
+	elem, err := cfg.Child("list.0", -1, PathSep("."))
+	require.NoError(t, err)
+	assert.Equal(t, "list.0", elem.Path("."))
+	first, err := cfg.Child("first", -1)
+	require.NoError(t, err)
+	assert.Equal(t, "first", first.Path("."))
+}
+
+func TestMoveFailedRestoresSource(t *testing.T) {
+	cfg := MustNewFrom(map[string]interface{}{
+		"a":     1,
+		"b":     2,
+		"c":     3,
+		"name":  "x",
+		"hosts": []interface{}{"h0", map[string]interface{}{"h": 1}, map[string]interface{}{"h": 2}},
+	}, PathSep("."))
+
+	// name is no object, such that setting name.sub fails
+	err := cfg.Move("hosts.1", "name.sub", PathSep("."))
+	require.Error(t, err)
+
+	var actual map[string]interface{}
+	require.NoError(t, cfg.Unpack(&actual))
+	assert.Equal(t, []interface{}{
+		"h0",
+		map[string]interface{}{"h": uint64(1)},
+		map[string]interface{}{"h": uint64(2)},
+	}, actual["hosts"])
+
+	for i := 1; i < 3; i++ {
+		path := fmt.Sprintf("hosts.%d", i)
+		elem, err := cfg.Child(path, -1, PathSep("."))
+		require.NoError(t, err)
+		assert.Equal(t, path, elem.Path("."))
+	}
+
+	err = cfg.Move("b", "name.sub", PathSep("."))
+	require.Error(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "hosts", "name"}, cfg.GetFields())
+}
+
+func TestMoveMissing(t *testing.T) {
+	cfg := MustNewFrom(map[string]interface{}{"a": 1})
+	err := cfg.Move("b", "c")
+	assert.True(t, errors.Is(err, ErrMissing))
+
+	err = cfg.Copy("b", "c")
+	assert.True(t, errors.Is(err, ErrMissing))
+}
+
+func TestCopy(t *testing.T) {
+	cfg := MustNewFrom(map[string]interface{}{
+		"a.x":   1,
+		"a.ref": "${b}",
+		"b":     "b-value",
+	}, PathSep("."), VarExp, MetaData(Meta{Source: "beat.yml"}))
+
+	require.NoError(t, cfg.Copy("a", "c.a", PathSep(".")))
+
+	meta, err := cfg.MetaOf("c.a.x", -1, PathSep("."))
+	require.NoError(t, err)
+	assert.Equal(t, "beat.yml", meta.Source)
+
+	// the copy is independent of the original
+	require.NoError(t, cfg.SetInt("c.a.x", -1, 2, PathSep(".")))
+	x, err := cfg.Int("a.x", -1, PathSep("."))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), x)
+
+	sub, err := cfg.Child("c.a", -1, PathSep("."))
+	require.NoError(t, err)
+	assert.Equal(t, "c.a", sub.Path("."))
+
+	ref, err := cfg.String("c.a.ref", -1, PathSep("."))
+	require.NoError(t, err)
+	assert.Equal(t, "b-value", ref)
+
+	// copying into its own namespace
+	require.NoError(t, cfg.Copy("a", "a.backup", PathSep(".")))
+	x, err = cfg.Int("a.backup.x", -1, PathSep("."))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), x)
+}
+
+func TestKeyOrder(t *testing.T) {
+	c, err := NewFrom(MapSlice{
+		{Key: "z", Value: 1},
+		{Key: "a", Value: MapSlice{{Key: "y", Value: 1}, {Key: "b", Value: 2}}},
+		{Key: "m.x", Value: 3},
+		{Key: "m.c", Value: 4},
+	}, PathSep("."))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"z", "a", "m"}, c.GetFields())
+
+	sub, err := c.Child("a", -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"y", "b"}, sub.GetFields())
+
+	sub, err = c.Child("m", -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"x", "c"}, sub.GetFields())
+
+	// existing keys keep their position, new keys are appended
+	err = c.Merge(MapSlice{{Key: "n", Value: 5}, {Key: "a", Value: MapSlice{{Key: "d", Value: 6}}}, {Key: "z", Value: 7}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"z", "a", "m", "n"}, c.GetFields())
+
+	sub, err = c.Child("a", -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"y", "b", "d"}, sub.GetFields())
+
+	// removing and adding a key again moves it to the end
+	_, err = c.Remove("a", -1)
+	assert.NoError(t, err)
+	assert.NoError(t, c.SetInt("a", -1, 8))
+	assert.Equal(t, []string{"z", "m", "n", "a"}, c.GetFields())
+
+	assert.Equal(t, c.GetFields(), c.Clone().GetFields())
+
+	// replacing a dictionary uses the order of the new dictionary
+	err = c.Merge(MapSlice{{Key: "n", Value: 1}, {Key: "z", Value: 2}}, ReplaceValues)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"n", "z"}, c.GetFields())
+}
+
+func TestKeyOrderGoMaps(t *testing.T) {
+	in := map[string]interface{}{}
+	keys := []string{"e", "b", "d", "a", "c", "f", "h", "g"}
+	for _, k := range keys {
+		in[k] = k
+	}
+
+	sort.Strings(keys)
+	for i := 0; i < 10; i++ {
+		c, err := NewFrom(in)
+		assert.NoError(t, err)
+		assert.Equal(t, keys, c.GetFields())
+	}
+}
+
+func makeLargeConfig(n int) map[string]interface{} {
+	in := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		sub := make(map[string]interface{}, 10)
+		for j := 0; j < 10; j++ {
+			sub[fmt.Sprintf("field%d", j)] = j
+		}
+		in[fmt.Sprintf("key%d", i)] = sub
+	}
+	return in
+}
+
+func BenchmarkNewFromLarge(b *testing.B) {
+	in := makeLargeConfig(1000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewFrom(in); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMergeLarge(b *testing.B) {
+	base := makeLargeConfig(1000)
+	overwrites := makeLargeConfig(1000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c := MustNewFrom(base)
+		if err := c.Merge(overwrites); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetFieldsLarge(b *testing.B) {
+	c := MustNewFrom(makeLargeConfig(1000))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = c.GetFields()
+	}
+}
+
+func BenchmarkRemoveLarge(b *testing.B) {
+	in := makeLargeConfig(1000)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		c := MustNewFrom(in)
+		b.StartTimer()
+
+		for k := range in {
+			if _, err := c.Remove(k, -1); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -221,7 +221,7 @@ func unpackWith(opts *options, v reflect.Value, with value) Error {
 	}
 
 	if err != nil {
-		return raisePathErr(err, meta, "", ctx.path("."))
+		return raisePathErr(err, meta, "", ctx.structuredPath())
 	}
 	return nil
 }
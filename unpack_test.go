@@ -19,9 +19,12 @@ package ucfg
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type stUnpackable struct {
@@ -237,3 +240,94 @@ func TestReifyUnpackersPtr(t *testing.T) {
 	assertSubConfig(to.C.c)
 	assertSubConfig(to.R.c)
 }
+
+// testTLSVersion simulates a third-party enum type without Unpack method.
+type testTLSVersion uint16
+
+type testEndpoint struct {
+	host string
+	port int
+}
+
+func TestConverter(t *testing.T) {
+	versions := Converter(func(v interface{}, path string) (testTLSVersion, error) {
+		switch v {
+		case "1.2":
+			return 0x0303, nil
+		case "1.3":
+			return 0x0304, nil
+		}
+		return 0, fmt.Errorf("unsupported TLS version %v", v)
+	})
+	endpoints := Converter(func(v interface{}, path string) (testEndpoint, error) {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return testEndpoint{}, fmt.Errorf("expected object at %v", path)
+		}
+		return testEndpoint{host: m["host"].(string), port: int(m["port"].(uint64))}, nil
+	})
+
+	c, _ := NewFrom(map[string]interface{}{
+		"min":      "1.2",
+		"versions": []string{"1.2", "1.3"},
+		"endpoint": map[string]interface{}{"host": "localhost", "port": 9200},
+	})
+
+	var settings struct {
+		Min      testTLSVersion
+		Versions []testTLSVersion
+		Endpoint *testEndpoint
+		Max      testTLSVersion
+	}
+	settings.Max = 0x0304
+	require.NoError(t, c.Unpack(&settings, versions, endpoints))
+
+	assert.Equal(t, testTLSVersion(0x0303), settings.Min)
+	assert.Equal(t, []testTLSVersion{0x0303, 0x0304}, settings.Versions)
+	assert.Equal(t, &testEndpoint{host: "localhost", port: 9200}, settings.Endpoint)
+	assert.Equal(t, testTLSVersion(0x0304), settings.Max)
+
+	// without converter the raw value is not convertible
+	assert.Error(t, c.Unpack(&settings))
+}
+
+func TestConverterFail(t *testing.T) {
+	conv := Converter(func(v interface{}, path string) (testTLSVersion, error) {
+		return 0, fmt.Errorf("unsupported TLS version %v at %v", v, path)
+	})
+
+	c, _ := NewFrom(map[string]interface{}{
+		"tls": map[string]interface{}{"min": "1.0"},
+	}, PathSep("."))
+
+	var settings struct {
+		Min testTLSVersion `config:"tls.min"`
+	}
+	err := c.Unpack(&settings, PathSep("."), conv)
+	require.Error(t, err)
+	assert.Equal(t, "tls.min", err.(Error).Path())
+	assert.Contains(t, err.Error(), "unsupported TLS version 1.0 at tls.min")
+}
+
+func TestConverterScoped(t *testing.T) {
+	upper := Converter(func(v interface{}, _ string) (string, error) {
+		return strings.ToUpper(fmt.Sprint(v)), nil
+	})
+	lower := Converter(func(v interface{}, _ string) (string, error) {
+		return strings.ToLower(fmt.Sprint(v)), nil
+	})
+
+	c, _ := NewFrom(map[string]interface{}{"name": "MixedCase"})
+
+	name, err := Get[string](c, "name", upper)
+	require.NoError(t, err)
+	assert.Equal(t, "MIXEDCASE", name)
+
+	name, err = Get[string](c, "name", upper, lower)
+	require.NoError(t, err)
+	assert.Equal(t, "mixedcase", name)
+
+	name, err = Get[string](c, "name")
+	require.NoError(t, err)
+	assert.Equal(t, "MixedCase", name)
+}
@@ -25,9 +25,13 @@ import (
 )
 
 type tagOptions struct {
-	squash      bool
-	ignore      bool
-	cfgHandling configHandling
+	squash        bool
+	ignore        bool
+	cfgHandling   configHandling
+	discriminator string
+	typeKey       bool
+	mergeKey      string
+	aliases       []string
 }
 
 // configHandling configures the operation to execute if we merge into a struct
@@ -41,6 +45,8 @@ const (
 	cfgArrAppend
 	cfgArrPrepend
 	cfgArrReplaceValue
+	cfgMergePatch
+	cfgMergeByKey
 )
 
 var noTagOpts = tagOptions{}
@@ -62,6 +68,19 @@ func parseTags(tag string) (string, tagOptions) {
 			opts.cfgHandling = cfgArrAppend
 		case "prepend":
 			opts.cfgHandling = cfgArrPrepend
+		case "mergepatch":
+			opts.cfgHandling = cfgMergePatch
+		case "typekey":
+			opts.typeKey = true
+		default:
+			if strings.HasPrefix(opt, "discriminator=") {
+				opts.discriminator = strings.TrimPrefix(opt, "discriminator=")
+			} else if strings.HasPrefix(opt, "mergekey=") {
+				opts.cfgHandling = cfgMergeByKey
+				opts.mergeKey = strings.TrimPrefix(opt, "mergekey=")
+			} else if strings.HasPrefix(opt, "alias=") {
+				opts.aliases = append(opts.aliases, strings.TrimPrefix(opt, "alias="))
+			}
 		}
 	}
 	return s[0], opts
@@ -167,6 +186,10 @@ func isUint(k reflect.Kind) bool {
 	}
 }
 
+func isNumberOrBool(k reflect.Kind) bool {
+	return k == reflect.Bool || isInt(k) || isUint(k) || isFloat(k)
+}
+
 func isFloat(k reflect.Kind) bool {
 	switch k {
 	case reflect.Float32, reflect.Float64:
@@ -183,6 +206,7 @@ type fieldInfo struct {
 	options       *options
 	tagOptions    tagOptions
 	validatorTags []validatorTag
+	deprecated    string
 }
 
 func accessField(structVal reflect.Value, fieldIdx int, opts *options) (fieldInfo, bool, Error) {
@@ -197,15 +221,22 @@ func accessField(structVal reflect.Value, fieldIdx int, opts *options) (fieldInf
 		return fieldInfo{}, true, nil
 	}
 
-	// create new context, overwriting configValueHandling for all sub-operations
-	if tagOpts.cfgHandling != opts.configValueHandling {
+	// create new context, overwriting configValueHandling and the type
+	// selection for all sub-operations
+	if tagOpts.cfgHandling != opts.configValueHandling ||
+		tagOpts.discriminator != opts.discriminator ||
+		tagOpts.typeKey != opts.typeKey ||
+		tagOpts.mergeKey != opts.mergeKey {
 		tmp := &options{}
 		*tmp = *opts
 		tmp.configValueHandling = tagOpts.cfgHandling
+		tmp.discriminator = tagOpts.discriminator
+		tmp.typeKey = tagOpts.typeKey
+		tmp.mergeKey = tagOpts.mergeKey
 		opts = tmp
 	}
 
-	validators, err := parseValidatorTags(stField.Tag.Get(opts.validatorTag))
+	validators, err := parseValidatorTags(stField.Tag.Get(opts.validatorTag), opts.validators)
 	if err != nil {
 		return fieldInfo{}, false, raiseCritical(err, "")
 	}
@@ -217,5 +248,28 @@ func accessField(structVal reflect.Value, fieldIdx int, opts *options) (fieldInf
 		options:       opts,
 		tagOptions:    tagOpts,
 		validatorTags: validators,
+		deprecated:    stField.Tag.Get(opts.deprecatedTag),
 	}, false, nil
 }
+
+// accessFields returns the info of all exported and not ignored fields of the
+// struct structVal. The cross field validator references of the fields are
+// checked against the struct type.
+func accessFields(structVal reflect.Value, opts *options) ([]fieldInfo, Error) {
+	numField := structVal.NumField()
+	fields := make([]fieldInfo, 0, numField)
+	for i := 0; i < numField; i++ {
+		fInfo, skip, err := accessField(structVal, i, opts)
+		if err != nil {
+			return nil, err
+		}
+		if !skip {
+			fields = append(fields, fInfo)
+		}
+	}
+
+	if err := checkCrossFieldRefs(structVal.Type(), fields, opts); err != nil {
+		return nil, raiseCritical(err, "")
+	}
+	return fields, nil
+}
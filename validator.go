@@ -19,11 +19,17 @@ package ucfg
 
 import (
 	"fmt"
+	"net"
+	"net/url"
+	"os"
 	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Validator interface provides additional validation support to Unpack. The
@@ -38,50 +44,179 @@ type Validator interface {
 }
 
 // ValidatorCallback is the type of optional validator tags to be registered via
-// RegisterValidator.
+// RegisterValidator or ValidatorRegistry.Register.
 type ValidatorCallback func(interface{}, string) error
 
 type validatorTag struct {
 	name  string
 	cb    ValidatorCallback
+	cross crossValidatorCallback
 	param string
 }
 
+// crossValidatorCallback validates a struct field against its sibling
+// fields. The lookup function returns the value of a sibling field by its
+// setting name.
+type crossValidatorCallback func(v reflect.Value, param string, lookup fieldLookup) error
+
+type fieldLookup func(name string) (reflect.Value, error)
+
+// ValidatorRegistry stores the validator callbacks available to the
+// "validate" struct tag, indexed by name. The zero value holds the built-in
+// validators only, like a registry created by NewValidatorRegistry. A
+// ValidatorRegistry is safe for concurrent use.
+type ValidatorRegistry struct {
+	mu         sync.RWMutex
+	validators map[string]ValidatorCallback
+}
+
 var (
-	validators = map[string]ValidatorCallback{}
+	// builtinValidators are registered with every new ValidatorRegistry.
+	builtinValidators = map[string]ValidatorCallback{
+		"nonzero":     validateNonZero,
+		"positive":    validatePositive,
+		"min":         validateMin,
+		"max":         validateMax,
+		"required":    validateRequired,
+		"oneof":       validateOneOf,
+		"pattern":     validatePattern,
+		"url":         validateURL,
+		"hostport":    validateHostPort,
+		"hostname":    validateHostname,
+		"ip":          validateIP,
+		"cidr":        validateCIDR,
+		"port":        validatePort,
+		"minlen":      validateMinLen,
+		"maxlen":      validateMaxLen,
+		"unique":      validateUnique,
+		"file_exists": validateFileExists,
+		"dir_exists":  validateDirExists,
+	}
+
+	crossValidators = map[string]crossValidatorCallback{
+		"required_if":    validateRequiredIf,
+		"required_with":  validateRequiredWith,
+		"excluded_with":  validateExcludedWith,
+		"exactly_one_of": validateExactlyOneOf,
+	}
+
+	// defaultValidators is the global registry used by RegisterValidator and
+	// by Unpack if the Validators option is not set.
+	defaultValidators = NewValidatorRegistry()
 )
 
-func init() {
-	initRegisterValidator("nonzero", validateNonZero)
-	initRegisterValidator("positive", validatePositive)
-	initRegisterValidator("min", validateMin)
-	initRegisterValidator("max", validateMax)
-	initRegisterValidator("required", validateRequired)
+// NewValidatorRegistry creates a new registry holding the built-in
+// validators only. Validators registered via RegisterValidator are not
+// available in the new registry. Use DefaultValidatorRegistry().Clone() to
+// create a registry extending the globally registered validators.
+func NewValidatorRegistry() *ValidatorRegistry {
+	return &ValidatorRegistry{validators: copyValidators(builtinValidators)}
+}
+
+// DefaultValidatorRegistry returns the global registry used by
+// RegisterValidator and by Unpack if the Validators option is not set.
+func DefaultValidatorRegistry() *ValidatorRegistry {
+	return defaultValidators
 }
 
-func initRegisterValidator(name string, cb ValidatorCallback) {
-	if err := RegisterValidator(name, cb); err != nil {
-		panic("Duplicate validator: " + name)
+// Clone creates a new registry holding all validators registered in r.
+// Validators registered afterwards are not shared between r and the clone.
+func (r *ValidatorRegistry) Clone() *ValidatorRegistry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.validators == nil {
+		return NewValidatorRegistry()
+	}
+	return &ValidatorRegistry{validators: copyValidators(r.validators)}
+}
+
+func copyValidators(validators map[string]ValidatorCallback) map[string]ValidatorCallback {
+	m := make(map[string]ValidatorCallback, len(validators))
+	for name, cb := range validators {
+		m[name] = cb
+	}
+	return m
+}
+
+// init initializes the validators of a zero value registry. The caller must
+// hold the write lock.
+func (r *ValidatorRegistry) init() {
+	if r.validators == nil {
+		r.validators = copyValidators(builtinValidators)
 	}
 }
 
 // RegisterValidator adds a new validator option to the "validate" struct tag.
 // The callback will be executed when unpacking into a struct field.
+//
+// RegisterValidator adds the validator to the global registry, shared by
+// all packages. Use NewValidatorRegistry and the Validators option to
+// register validators for a single Unpack call only.
 func RegisterValidator(name string, cb ValidatorCallback) error {
-	if _, exists := validators[name]; exists {
+	return defaultValidators.Register(name, cb)
+}
+
+// Register adds a new validator option to the registry. Register returns
+// ErrDuplicateValidator if a validator with the same name is already
+// registered.
+func (r *ValidatorRegistry) Register(name string, cb ValidatorCallback) error {
+	if _, exists := crossValidators[name]; exists {
 		return ErrDuplicateValidator
 	}
 
-	validators[name] = cb
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.init()
+	if _, exists := r.validators[name]; exists {
+		return ErrDuplicateValidator
+	}
+	r.validators[name] = cb
 	return nil
 }
 
-func parseValidatorTags(tag string) ([]validatorTag, error) {
+// Unregister removes the validator name from the registry. Unregister
+// returns false if the validator was not registered.
+func (r *ValidatorRegistry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.init()
+	if _, exists := r.validators[name]; !exists {
+		return false
+	}
+	delete(r.validators, name)
+	return true
+}
+
+// Has checks if a validator with the given name is registered.
+func (r *ValidatorRegistry) Has(name string) bool {
+	_, exists := r.lookup(name)
+	return exists
+}
+
+func (r *ValidatorRegistry) lookup(name string) (ValidatorCallback, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.validators == nil {
+		cb, exists := builtinValidators[name]
+		return cb, exists
+	}
+	cb, exists := r.validators[name]
+	return cb, exists
+}
+
+func parseValidatorTags(tag string, registry *ValidatorRegistry) ([]validatorTag, error) {
 	if tag == "" {
 		return nil, nil
 	}
+	if registry == nil {
+		registry = defaultValidators
+	}
 
-	lst := strings.Split(tag, ",")
+	lst := splitValidatorTags(tag)
 	if len(lst) == 0 {
 		return nil, nil
 	}
@@ -90,8 +225,9 @@ func parseValidatorTags(tag string) ([]validatorTag, error) {
 	for _, cfg := range lst {
 		v := strings.SplitN(cfg, "=", 2)
 		name := strings.Trim(v[0], " \t\r\n")
-		cb := validators[name]
-		if cb == nil {
+		cb, _ := registry.lookup(name)
+		cross := crossValidators[name]
+		if cb == nil && cross == nil {
 			return nil, fmt.Errorf("unknown validator '%v'", name)
 		}
 
@@ -100,12 +236,33 @@ func parseValidatorTags(tag string) ([]validatorTag, error) {
 			param = strings.Trim(v[1], " \t\r\n")
 		}
 
-		tags = append(tags, validatorTag{name: name, cb: cb, param: param})
+		tags = append(tags, validatorTag{name: name, cb: cb, cross: cross, param: param})
 	}
 
 	return tags, nil
 }
 
+// splitValidatorTags splits the validate tag into its validators, separated
+// by commas. Commas escaped as '\,' are kept in the parameter of a
+// validator, like in `pattern=^a{1\,3}$`.
+func splitValidatorTags(tag string) []string {
+	var lst []string
+	var b strings.Builder
+	for i := 0; i < len(tag); i++ {
+		switch {
+		case tag[i] == '\\' && i+1 < len(tag) && tag[i+1] == ',':
+			b.WriteByte(',')
+			i++
+		case tag[i] == ',':
+			lst = append(lst, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(tag[i])
+		}
+	}
+	return append(lst, b.String())
+}
+
 func tryValidate(val reflect.Value) error {
 	t := val.Type()
 	var validator Validator
@@ -132,6 +289,10 @@ func runValidators(val interface{}, validators []validatorTag) error {
 		return nil
 	}
 	for _, tag := range validators {
+		if tag.cb == nil {
+			// cross field validators are run once the struct is unpacked
+			continue
+		}
 		if err := tag.cb(val, tag.param); err != nil {
 			return err
 		}
@@ -174,23 +335,365 @@ func tryRecursiveValidate(val reflect.Value, opts *options, validators []validat
 
 func validateStruct(val reflect.Value, opts *options) error {
 	val = chaseValue(val)
-	numField := val.NumField()
-	for i := 0; i < numField; i++ {
-		fInfo, skip, err := accessField(val, i, opts)
-		if err != nil {
+	fields, err := accessFields(val, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, fInfo := range fields {
+		if err := tryRecursiveValidate(fInfo.value, fInfo.options, fInfo.validatorTags); err != nil {
 			return err
 		}
-		if skip {
+	}
+
+	_, verr := validateCrossFields(fields)
+	return verr
+}
+
+// validateCrossFields runs the cross field validators of the fields of a
+// struct. If validation fails, the name of the failing field is returned.
+func validateCrossFields(fields []fieldInfo) (string, error) {
+	var lookup fieldLookup
+	for _, fInfo := range fields {
+		for _, tag := range fInfo.validatorTags {
+			if tag.cross == nil {
+				continue
+			}
+			if lookup == nil {
+				idx, err := newFieldIndex(fields)
+				if err != nil {
+					return "", err
+				}
+				lookup = idx.lookup
+			}
+			if err := tag.cross(fInfo.value, tag.param, lookup); err != nil {
+				return fInfo.name, err
+			}
+		}
+	}
+	return "", nil
+}
+
+// checkCrossFieldRefs checks the sibling fields referenced by the cross field
+// validators of fields to exist in the struct type t.
+func checkCrossFieldRefs(t reflect.Type, fields []fieldInfo, opts *options) error {
+	var types map[string]reflect.Type
+	for _, fInfo := range fields {
+		for _, tag := range fInfo.validatorTags {
+			if tag.cross == nil {
+				continue
+			}
+
+			names := strings.Fields(tag.param)
+			if tag.name == "required_if" {
+				if len(names) == 0 || len(names)%2 != 0 {
+					return fmt.Errorf("required_if requires pairs of field and value, got '%v'", tag.param)
+				}
+				refs := make([]string, 0, len(names)/2)
+				for i := 0; i < len(names); i += 2 {
+					refs = append(refs, names[i])
+				}
+				names = refs
+			}
+
+			if types == nil {
+				types = map[string]reflect.Type{}
+				indexStructFieldTypes(types, t, opts)
+			}
+			for _, name := range names {
+				if !hasStructFieldType(types, name, opts) {
+					return fmt.Errorf("unknown field '%v' in validator", name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// indexStructFieldTypes adds the types of the fields of the struct type t to
+// types, keyed by setting name. Fields of inline structs are indexed as if
+// they were fields of t. The first field with a name wins.
+func indexStructFieldTypes(types map[string]reflect.Type, t reflect.Type, opts *options) {
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		stField := t.Field(i)
+		if r, _ := utf8.DecodeRuneInString(stField.Name); !unicode.IsUpper(r) {
+			continue
+		}
+		tagName, tagOpts := parseTags(stField.Tag.Get(opts.tag))
+		if tagOpts.ignore {
 			continue
 		}
 
-		if err := tryRecursiveValidate(fInfo.value, fInfo.options, fInfo.validatorTags); err != nil {
+		if tagOpts.squash {
+			indexStructFieldTypes(types, chaseTypePointers(stField.Type), opts)
+			continue
+		}
+		name := fieldName(tagName, stField.Name)
+		if _, exists := types[name]; !exists {
+			types[name] = stField.Type
+		}
+	}
+}
+
+// hasStructFieldType checks if the indexed struct field types have a field
+// with the setting name. Names of nested fields are separated by '.'. Fields
+// nested in interface types can not be checked and are assumed to exist.
+func hasStructFieldType(types map[string]reflect.Type, name string, opts *options) bool {
+	elems := strings.Split(name, ".")
+	t, found := types[elems[0]]
+	if !found {
+		return false
+	}
+
+	for _, elem := range elems[1:] {
+		t = chaseTypePointers(t)
+		if t.Kind() == reflect.Interface {
+			return true
+		}
+
+		t, found = findStructFieldType(t, elem, opts)
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func findStructFieldType(t reflect.Type, name string, opts *options) (reflect.Type, bool) {
+	if t.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		stField := t.Field(i)
+		if r, _ := utf8.DecodeRuneInString(stField.Name); !unicode.IsUpper(r) {
+			continue
+		}
+		tagName, tagOpts := parseTags(stField.Tag.Get(opts.tag))
+		if tagOpts.ignore {
+			continue
+		}
+
+		if tagOpts.squash {
+			if field, found := findStructFieldType(chaseTypePointers(stField.Type), name, opts); found {
+				return field, true
+			}
+			continue
+		}
+		if fieldName(tagName, stField.Name) == name {
+			return stField.Type, true
+		}
+	}
+	return nil, false
+}
+
+// fieldIndex maps the setting names of the fields of a struct to their field
+// info. Fields of inline structs are indexed as if they were fields of the
+// struct. Indexes of nested structs are built on first lookup.
+type fieldIndex struct {
+	fields map[string]fieldInfo
+	nested map[string]*fieldIndex
+}
+
+func newFieldIndex(fields []fieldInfo) (*fieldIndex, Error) {
+	idx := &fieldIndex{fields: map[string]fieldInfo{}}
+	if err := idx.add(fields); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+func (idx *fieldIndex) add(fields []fieldInfo) Error {
+	for _, fInfo := range fields {
+		if !fInfo.tagOptions.squash {
+			if _, exists := idx.fields[fInfo.name]; !exists {
+				idx.fields[fInfo.name] = fInfo
+			}
+			continue
+		}
+
+		inline := chaseValue(fInfo.value)
+		if inline.Kind() != reflect.Struct {
+			continue
+		}
+		inlineFields, err := accessFields(inline, fInfo.options)
+		if err != nil {
+			return err
+		}
+		if err := idx.add(inlineFields); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// lookup returns the value of the field with the setting name. Names of
+// nested fields are separated by '.'. The returned value is invalid if a
+// parent struct pointer of the field is nil.
+func (idx *fieldIndex) lookup(name string) (reflect.Value, error) {
+	elems := strings.Split(name, ".")
+	for i, elem := range elems {
+		fInfo, found := idx.fields[elem]
+		if !found {
+			return reflect.Value{}, fmt.Errorf("unknown field '%v' in validator", name)
+		}
+		if i == len(elems)-1 {
+			return fInfo.value, nil
+		}
+
+		val := chaseValue(fInfo.value)
+		if !val.IsValid() || val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+			return reflect.Value{}, nil
+		}
+
+		var err error
+		if idx, err = idx.nestedIndex(elem, val, fInfo.options); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	return reflect.Value{}, nil
+}
+
+func (idx *fieldIndex) nestedIndex(name string, val reflect.Value, opts *options) (*fieldIndex, error) {
+	if nested, exists := idx.nested[name]; exists {
+		return nested, nil
+	}
+
+	nested := &fieldIndex{fields: map[string]fieldInfo{}}
+	if val.Kind() == reflect.Struct {
+		fields, err := accessFields(val, opts)
+		if err != nil {
+			return nil, err
+		}
+		if err := nested.add(fields); err != nil {
+			return nil, err
+		}
+	}
+
+	if idx.nested == nil {
+		idx.nested = map[string]*fieldIndex{}
+	}
+	idx.nested[name] = nested
+	return nested, nil
+}
+
+// isFieldSet checks if the field value v is not nil, zero or empty.
+func isFieldSet(v reflect.Value) bool {
+	v = chaseValue(v)
+	if !v.IsValid() {
+		return false
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return false
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() > 0
+	default:
+		return !v.IsZero()
+	}
+}
+
+// findSetField returns the name of the first field being set, or an empty
+// string if none of the fields is set.
+func findSetField(names []string, lookup fieldLookup) (string, error) {
+	for _, name := range names {
+		field, err := lookup(name)
+		if err != nil {
+			return "", err
+		}
+		if isFieldSet(field) {
+			return name, nil
+		}
+	}
+	return "", nil
+}
+
+// formatField formats the field value v for comparing it with validator
+// parameters. Nil values are formatted as empty string.
+func formatField(v reflect.Value) string {
+	v = chaseValue(v)
+	if !v.IsValid() || ((v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil()) {
+		return ""
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// validateRequiredIf implements the `required_if=<field> <value> ...` validation
+// tag. The field is required if all listed fields have the given values.
+func validateRequiredIf(v reflect.Value, param string, lookup fieldLookup) error {
+	args := strings.Fields(param)
+	if len(args) == 0 || len(args)%2 != 0 {
+		return fmt.Errorf("required_if requires pairs of field and value, got '%v'", param)
+	}
+
+	var conditions []string
+	for i := 0; i < len(args); i += 2 {
+		field, err := lookup(args[i])
+		if err != nil {
+			return err
+		}
+		if formatField(field) != args[i+1] {
+			return nil
+		}
+		conditions = append(conditions, fmt.Sprintf("'%v' is '%v'", args[i], args[i+1]))
+	}
+
+	if isFieldSet(v) {
+		return nil
+	}
+	return fmt.Errorf("%w when %v", ErrRequired, strings.Join(conditions, " and "))
+}
+
+// validateRequiredWith implements the `required_with=<field> ...` validation
+// tag. The field is required if any of the listed fields is set.
+func validateRequiredWith(v reflect.Value, param string, lookup fieldLookup) error {
+	name, err := findSetField(strings.Fields(param), lookup)
+	if err != nil || name == "" || isFieldSet(v) {
+		return err
+	}
+	return fmt.Errorf("%w when '%v' is set", ErrRequired, name)
+}
+
+// validateExcludedWith implements the `excluded_with=<field> ...` validation
+// tag. The field must not be set if any of the listed fields is set.
+func validateExcludedWith(v reflect.Value, param string, lookup fieldLookup) error {
+	name, err := findSetField(strings.Fields(param), lookup)
+	if err != nil || name == "" || !isFieldSet(v) {
+		return err
+	}
+	return fmt.Errorf("%w when '%v' is set", ErrExcluded, name)
+}
+
+// validateExactlyOneOf implements the `exactly_one_of=<field> ...` validation
+// tag. Exactly one of the field and the listed fields must be set.
+func validateExactlyOneOf(v reflect.Value, param string, lookup fieldLookup) error {
+	names := strings.Fields(param)
+	count := 0
+	if isFieldSet(v) {
+		count++
+	}
+	for _, name := range names {
+		field, err := lookup(name)
+		if err != nil {
+			return err
+		}
+		if isFieldSet(field) {
+			count++
+		}
+	}
+
+	if count == 1 {
+		return nil
+	}
+	return fmt.Errorf("%w, %v of the field and '%v' are set",
+		ErrExactlyOne, count, strings.Join(names, "', '"))
+}
+
 func validateMap(val reflect.Value, opts *options) error {
 	for _, key := range val.MapKeys() {
 		if err := tryRecursiveValidate(val.MapIndex(key), opts, nil); err != nil {
@@ -446,6 +949,330 @@ func validateNonEmptyWithAllowNil(v interface{}, _ string, allowNil bool) error
 	return nil
 }
 
+// validateEach runs fn on v, or on each element if v is an array or slice.
+// Byte slices like net.IP are passed to fn as is.
+func validateEach(v interface{}, fn func(reflect.Value) error) error {
+	if v == nil {
+		return nil
+	}
+
+	val := chaseValue(reflect.ValueOf(v))
+	switch val.Kind() {
+	case reflect.Array, reflect.Slice:
+		if val.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		for i := 0; i < val.Len(); i++ {
+			if err := validateEach(val.Index(i).Interface(), fn); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Ptr, reflect.Interface:
+		return nil
+	}
+	return fn(val)
+}
+
+// validateEachString runs fn on each non-empty string in v. Other types are
+// ignored, as they have already been checked when unpacking.
+func validateEachString(v interface{}, fn func(string) error) error {
+	return validateEach(v, func(val reflect.Value) error {
+		if val.Kind() != reflect.String || val.Len() == 0 {
+			return nil
+		}
+		return fn(val.String())
+	})
+}
+
+// validateOneOf implements the `oneof=<value> ...` validation tag. It checks
+// non-empty strings and numbers to be one of the space separated values.
+func validateOneOf(v interface{}, param string) error {
+	allowed := strings.Fields(param)
+	return validateEach(v, func(val reflect.Value) error {
+		if !isNumberOrBool(val.Kind()) && val.Kind() != reflect.String {
+			return nil
+		}
+		if val.Kind() == reflect.String && val.Len() == 0 {
+			return nil
+		}
+
+		s := fmt.Sprint(val.Interface())
+		for _, a := range allowed {
+			if s == a {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: '%v' is not one of '%v'", ErrNotOneOf, s, strings.Join(allowed, "', '"))
+	})
+}
+
+// validatePattern implements the `pattern=<regexp>` validation tag. It checks
+// non-empty strings to match the regular expression. Commas in the expression
+// must be escaped as '\,'.
+func validatePattern(v interface{}, param string) error {
+	re, err := compilePattern(param)
+	if err != nil {
+		return err
+	}
+	return validateEachString(v, func(s string) error {
+		if !re.MatchString(s) {
+			return fmt.Errorf("%w: '%v' does not match '%v'", ErrPatternMismatch, s, param)
+		}
+		return nil
+	})
+}
+
+// patternCache stores the compiled regular expressions of the pattern
+// validator by expression, such that each expression is compiled once.
+var patternCache sync.Map
+
+func compilePattern(expr string) (*regexp.Regexp, error) {
+	if re, ok := patternCache.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	patternCache.Store(expr, re)
+	return re, nil
+}
+
+// validateURL implements the `url` validation tag. It checks non-empty
+// strings to be absolute URLs. The optional parameter lists the allowed
+// schemes, separated by spaces (e.g. `url=http https`).
+func validateURL(v interface{}, param string) error {
+	schemes := strings.Fields(param)
+	return validateEachString(v, func(s string) error {
+		u, err := url.Parse(s)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidURL, err)
+		}
+		if u.Scheme == "" || (u.Host == "" && u.Path == "" && u.Opaque == "") {
+			return fmt.Errorf("%w: '%v' is no absolute URL", ErrInvalidURL, s)
+		}
+		if len(schemes) == 0 {
+			return nil
+		}
+		for _, scheme := range schemes {
+			if strings.EqualFold(u.Scheme, scheme) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: scheme of '%v' is not one of '%v'",
+			ErrInvalidURL, s, strings.Join(schemes, "', '"))
+	})
+}
+
+// validateHostPort implements the `hostport` validation tag. It checks
+// non-empty strings to be of the form `host:port`.
+func validateHostPort(v interface{}, _ string) error {
+	return validateEachString(v, func(s string) error {
+		host, port, err := net.SplitHostPort(s)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidHostPort, err)
+		}
+		if host == "" {
+			return fmt.Errorf("%w: missing host in '%v'", ErrInvalidHostPort, s)
+		}
+		if p, err := parsePort(port); err != nil || p == 0 {
+			return fmt.Errorf("%w: invalid port in '%v'", ErrInvalidHostPort, s)
+		}
+		return nil
+	})
+}
+
+// validateHostname implements the `hostname` validation tag. It checks
+// non-empty strings to be host names following RFC 1123.
+func validateHostname(v interface{}, _ string) error {
+	return validateEachString(v, func(s string) error {
+		if !isHostname(s) {
+			return fmt.Errorf("%w: '%v'", ErrInvalidHostname, s)
+		}
+		return nil
+	})
+}
+
+func isHostname(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	if s == "" || len(s) > 253 {
+		return false
+	}
+
+	for _, label := range strings.Split(s, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for i := 0; i < len(label); i++ {
+			c := label[i]
+			isAlnum := ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+			if !isAlnum && c != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// validateIP implements the `ip` validation tag. It checks non-empty strings
+// to be IPv4 or IPv6 addresses.
+func validateIP(v interface{}, _ string) error {
+	return validateEachString(v, func(s string) error {
+		if net.ParseIP(s) == nil {
+			return fmt.Errorf("%w: '%v'", ErrInvalidIP, s)
+		}
+		return nil
+	})
+}
+
+// validateCIDR implements the `cidr` validation tag. It checks non-empty
+// strings to be IP networks in CIDR notation.
+func validateCIDR(v interface{}, _ string) error {
+	return validateEachString(v, func(s string) error {
+		if _, _, err := net.ParseCIDR(s); err != nil {
+			return fmt.Errorf("%w: '%v'", ErrInvalidCIDR, s)
+		}
+		return nil
+	})
+}
+
+// validatePort implements the `port` validation tag. It checks non-zero
+// numbers and non-empty strings to be port numbers between 1 and 65535. Zero
+// is ignored, also if given as string.
+func validatePort(v interface{}, _ string) error {
+	return validateEach(v, func(val reflect.Value) error {
+		var port int64
+		switch {
+		case isInt(val.Kind()):
+			port = val.Int()
+		case isUint(val.Kind()):
+			if val.Uint() > 65535 {
+				port = -1
+			} else {
+				port = int64(val.Uint())
+			}
+		case val.Kind() == reflect.String && val.Len() > 0:
+			p, err := parsePort(val.String())
+			if err != nil {
+				return err
+			}
+			port = p
+		default:
+			return nil
+		}
+
+		if port == 0 {
+			return nil
+		}
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("%w: %v is not between 1 and 65535", ErrInvalidPort, val.Interface())
+		}
+		return nil
+	})
+}
+
+// parsePort parses a port number between 0 and 65535.
+func parsePort(s string) (int64, error) {
+	port, err := strconv.ParseUint(s, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("%w: '%v' is not between 1 and 65535", ErrInvalidPort, s)
+	}
+	return int64(port), nil
+}
+
+// validateMinLen implements the `minlen=<n>` validation tag. It checks the
+// number of characters of strings, and the length of arrays, slices and maps
+// to be >= n.
+func validateMinLen(v interface{}, param string) error {
+	min, err := strconv.Atoi(param)
+	if err != nil {
+		return err
+	}
+	if n, ok := valueLen(v); ok && n < min {
+		return fmt.Errorf("%w: requires length >= %v", ErrTooShort, min)
+	}
+	return nil
+}
+
+// validateMaxLen implements the `maxlen=<n>` validation tag. It checks the
+// number of characters of strings, and the length of arrays, slices and maps
+// to be <= n.
+func validateMaxLen(v interface{}, param string) error {
+	max, err := strconv.Atoi(param)
+	if err != nil {
+		return err
+	}
+	if n, ok := valueLen(v); ok && n > max {
+		return fmt.Errorf("%w: requires length <= %v", ErrTooLong, max)
+	}
+	return nil
+}
+
+// valueLen returns the length of strings, arrays, slices and maps. Other
+// types and nil values are ignored.
+func valueLen(v interface{}) (int, bool) {
+	if v == nil {
+		return 0, false
+	}
+
+	val := chaseValue(reflect.ValueOf(v))
+	switch val.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(val.String()), true
+	case reflect.Array, reflect.Slice, reflect.Map:
+		return val.Len(), true
+	default:
+		return 0, false
+	}
+}
+
+// validateUnique implements the `unique` validation tag. It checks arrays and
+// slices not to contain duplicate elements.
+func validateUnique(v interface{}, _ string) error {
+	if v == nil {
+		return nil
+	}
+
+	val := chaseValue(reflect.ValueOf(v))
+	if val.Kind() != reflect.Array && val.Kind() != reflect.Slice {
+		return nil
+	}
+	for i := 1; i < val.Len(); i++ {
+		for j := 0; j < i; j++ {
+			if reflect.DeepEqual(val.Index(i).Interface(), val.Index(j).Interface()) {
+				return fmt.Errorf("%w: '%v' at index %v and %v", ErrNotUnique, val.Index(i).Interface(), j, i)
+			}
+		}
+	}
+	return nil
+}
+
+// validateFileExists implements the `file_exists` validation tag. It checks
+// non-empty strings to be paths of existing files.
+func validateFileExists(v interface{}, _ string) error {
+	return validateEachString(v, func(s string) error {
+		info, err := os.Stat(s)
+		if err != nil || info.IsDir() {
+			return fmt.Errorf("%w: '%v'", ErrFileNotFound, s)
+		}
+		return nil
+	})
+}
+
+// validateDirExists implements the `dir_exists` validation tag. It checks
+// non-empty strings to be paths of existing directories.
+func validateDirExists(v interface{}, _ string) error {
+	return validateEachString(v, func(s string) error {
+		info, err := os.Stat(s)
+		if err != nil || !info.IsDir() {
+			return fmt.Errorf("%w: '%v'", ErrDirNotFound, s)
+		}
+		return nil
+	})
+}
+
 func param2Duration(param string) (time.Duration, error) {
 	d, err := time.ParseDuration(param)
 	if err == nil {
@@ -20,11 +20,17 @@ package ucfg
 import (
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
 	"github.com/elastic/go-ucfg/cfgtest"
 )
 
@@ -991,3 +997,498 @@ func TestValidationFailOnDefaults(t *testing.T) {
 		})
 	}
 }
+
+type crossFieldTLS struct {
+	Enabled     bool   `config:"enabled"`
+	Mode        string `config:"mode"`
+	Certificate string `config:"certificate"`
+	Key         string `config:"key" validate:"required_with=certificate"`
+	CA          string `config:"ca" validate:"required_if=mode verify enabled true"`
+	Insecure    bool   `config:"insecure" validate:"excluded_with=ca"`
+}
+
+type crossFieldCloud struct {
+	ID string `config:"id"`
+}
+
+type crossFieldOutput struct {
+	Hosts []string         `config:"hosts" validate:"exactly_one_of=cloud.id"`
+	Cloud *crossFieldCloud `config:"cloud"`
+	TLS   crossFieldTLS    `config:"ssl"`
+}
+
+func TestValidateCrossFields(t *testing.T) {
+	tests := map[string]struct {
+		in     map[string]interface{}
+		reason error
+		path   string
+	}{
+		"hosts only": {
+			in: map[string]interface{}{"output.hosts": []string{"a"}},
+		},
+		"cloud only": {
+			in: map[string]interface{}{"output.cloud.id": "abc"},
+		},
+		"hosts and cloud": {
+			in: map[string]interface{}{
+				"output.hosts":    []string{"a"},
+				"output.cloud.id": "abc",
+			},
+			reason: ErrExactlyOne,
+			path:   "output.hosts",
+		},
+		"no hosts and no cloud": {
+			in:     map[string]interface{}{"output.ssl.enabled": true},
+			reason: ErrExactlyOne,
+			path:   "output.hosts",
+		},
+		"certificate and key": {
+			in: map[string]interface{}{
+				"output.hosts":           []string{"a"},
+				"output.ssl.certificate": "cert.pem",
+				"output.ssl.key":         "key.pem",
+			},
+		},
+		"certificate without key": {
+			in: map[string]interface{}{
+				"output.hosts":           []string{"a"},
+				"output.ssl.certificate": "cert.pem",
+			},
+			reason: ErrRequired,
+			path:   "output.ssl.key",
+		},
+		"verify mode without ca": {
+			in: map[string]interface{}{
+				"output.hosts":       []string{"a"},
+				"output.ssl.enabled": true,
+				"output.ssl.mode":    "verify",
+			},
+			reason: ErrRequired,
+			path:   "output.ssl.ca",
+		},
+		"verify mode disabled": {
+			in: map[string]interface{}{
+				"output.hosts":    []string{"a"},
+				"output.ssl.mode": "verify",
+			},
+		},
+		"insecure with ca": {
+			in: map[string]interface{}{
+				"output.hosts":        []string{"a"},
+				"output.ssl.ca":       "ca.pem",
+				"output.ssl.insecure": true,
+			},
+			reason: ErrExcluded,
+			path:   "output.ssl.insecure",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := MustNewFrom(test.in, PathSep("."))
+
+			var settings struct {
+				Output crossFieldOutput `config:"output"`
+			}
+			err := cfg.Unpack(&settings)
+			if test.reason == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, test.reason), "unexpected error: %v", err)
+			assert.Equal(t, test.path, err.(Error).Path())
+		})
+	}
+}
+
+func TestValidateCrossFieldsInline(t *testing.T) {
+	type auth struct {
+		Username string `config:"username"`
+		Password string `config:"password" validate:"required_with=username"`
+	}
+	type settings struct {
+		Auth  auth             `config:",inline"`
+		Token string           `config:"token" validate:"excluded_with=username"`
+		Cloud *crossFieldCloud `config:"cloud"`
+		Hosts []string         `config:"hosts" validate:"exactly_one_of=cloud.id"`
+	}
+
+	tests := map[string]struct {
+		in     map[string]interface{}
+		reason error
+		path   string
+	}{
+		"inline fields set": {
+			in: map[string]interface{}{
+				"hosts":    []string{"a"},
+				"username": "user",
+				"password": "pass",
+			},
+		},
+		"inline field missing": {
+			in: map[string]interface{}{
+				"hosts":    []string{"a"},
+				"username": "user",
+			},
+			reason: ErrRequired,
+			path:   "password",
+		},
+		"excluded by inline field": {
+			in: map[string]interface{}{
+				"hosts":    []string{"a"},
+				"username": "user",
+				"password": "pass",
+				"token":    "abc",
+			},
+			reason: ErrExcluded,
+			path:   "token",
+		},
+		"nested field set": {
+			in: map[string]interface{}{"cloud.id": "abc"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := MustNewFrom(test.in, PathSep("."))
+
+			var to settings
+			err := cfg.Unpack(&to)
+			if test.reason == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, test.reason), "unexpected error: %v", err)
+			assert.Equal(t, test.path, err.(Error).Path())
+		})
+	}
+}
+
+func BenchmarkUnpackCrossFields(b *testing.B) {
+	cfg := MustNewFrom(map[string]interface{}{
+		"hosts":           []string{"a"},
+		"ssl.enabled":     true,
+		"ssl.mode":        "verify",
+		"ssl.certificate": "cert.pem",
+		"ssl.key":         "key.pem",
+		"ssl.ca":          "ca.pem",
+	}, PathSep("."))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var settings crossFieldOutput
+		if err := cfg.Unpack(&settings); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestValidateCrossFieldsMessage(t *testing.T) {
+	cfg := MustNewFrom(map[string]interface{}{
+		"hosts":    []string{"a"},
+		"cloud.id": "abc",
+	}, PathSep("."), MetaData(Meta{Source: "beat.yml"}))
+
+	var settings crossFieldOutput
+	err := cfg.Unpack(&settings)
+	require.Error(t, err)
+	assert.Equal(t, "exactly one field must be set, 2 of the field and 'cloud.id' are set accessing 'hosts' (source:'beat.yml')", err.Error())
+
+	cfg = MustNewFrom(map[string]interface{}{
+		"hosts":        []string{"a"},
+		"ssl.enabled":  true,
+		"ssl.mode":     "verify",
+		"ssl.insecure": true,
+	}, PathSep("."))
+	err = cfg.Unpack(&settings)
+	require.Error(t, err)
+	assert.Equal(t, "missing required field when 'mode' is 'verify' and 'enabled' is 'true' accessing 'ssl.ca'", err.Error())
+}
+
+func TestValidateCrossFieldsUnknownField(t *testing.T) {
+	type ssl struct {
+		Key string `config:"key"`
+	}
+
+	tests := map[string]struct {
+		to      interface{}
+		message string
+	}{
+		"unknown sibling": {
+			to: &struct {
+				A string `validate:"required_with=missing"`
+			}{},
+			message: "unknown field 'missing' in validator",
+		},
+		"unknown nested field of unset struct": {
+			to: &struct {
+				SSL *ssl   `config:"ssl"`
+				A   string `validate:"excluded_with=ssl.missing"`
+			}{},
+			message: "unknown field 'ssl.missing' in validator",
+		},
+		"unknown field in condition": {
+			to: &struct {
+				A string `validate:"required_if=missing x"`
+			}{},
+			message: "unknown field 'missing' in validator",
+		},
+		"missing condition value": {
+			to: &struct {
+				B string
+				A string `validate:"required_if=b"`
+			}{},
+			message: "required_if requires pairs of field and value, got 'b'",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// references are checked even if no field is set
+			err := New().Unpack(test.to)
+			require.Error(t, err)
+			assert.Equal(t, ErrImplementation, err.(Error).Class())
+			assert.Contains(t, err.Error(), test.message)
+		})
+	}
+}
+
+func TestBuiltinValidators(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file.txt")
+	require.NoError(t, os.WriteFile(file, nil, 0o600))
+
+	tests := []struct {
+		tag    string
+		value  interface{}
+		reason error
+	}{
+		{"oneof=a b c", "b", nil},
+		{"oneof=a b c", "", nil},
+		{"oneof=a b c", "d", ErrNotOneOf},
+		{"oneof=a b c", []string{"a", "c"}, nil},
+		{"oneof=a b c", []string{"a", "x"}, ErrNotOneOf},
+		{"oneof=1 2", 2, nil},
+		{"oneof=1 2", 3, ErrNotOneOf},
+
+		{"pattern=^[a-z]+$", "abc", nil},
+		{"pattern=^[a-z]+$", "ABC", ErrPatternMismatch},
+		{"pattern=^[a-z]+$", []string{"a", "b1"}, ErrPatternMismatch},
+		{`pattern=^a{1\,3}$`, "aaa", nil},
+		{`pattern=^a{1\,3}$`, "aaaa", ErrPatternMismatch},
+		{`pattern=^[a-z]\,[a-z]$,maxlen=3`, "a,b", nil},
+		{`pattern=^[a-z]\,[a-z]$,maxlen=2`, "a,b", ErrTooLong},
+
+		{"url", "http://localhost:9200/path", nil},
+		{"url", "file:///var/log", nil},
+		{"url", "localhost", ErrInvalidURL},
+		{"url", "http://[::1", ErrInvalidURL},
+		{"url=http https", "https://localhost", nil},
+		{"url=http https", "ftp://localhost", ErrInvalidURL},
+
+		{"hostport", "localhost:9200", nil},
+		{"hostport", "[::1]:9200", nil},
+		{"hostport", []string{"a:1", "b:2"}, nil},
+		{"hostport", "localhost", ErrInvalidHostPort},
+		{"hostport", ":9200", ErrInvalidHostPort},
+		{"hostport", "localhost:http", ErrInvalidHostPort},
+		{"hostport", "localhost:70000", ErrInvalidHostPort},
+		{"hostport", "localhost:0", ErrInvalidHostPort},
+
+		{"hostname", "localhost", nil},
+		{"hostname", "es-1.example.com.", nil},
+		{"hostname", []string{"a", "b.c"}, nil},
+		{"hostname", "-es.example.com", ErrInvalidHostname},
+		{"hostname", "es_1.example.com", ErrInvalidHostname},
+		{"hostname", "a..b", ErrInvalidHostname},
+		{"hostname", "localhost:9200", ErrInvalidHostname},
+
+		{"ip", "127.0.0.1", nil},
+		{"ip", "::1", nil},
+		{"ip", "localhost", ErrInvalidIP},
+		{"ip", []string{"10.0.0.1", "10.0.0"}, ErrInvalidIP},
+
+		{"cidr", "10.0.0.0/8", nil},
+		{"cidr", "10.0.0.1", ErrInvalidCIDR},
+
+		{"port", 9200, nil},
+		{"port", 0, nil},
+		{"port", "9200", nil},
+		{"port", "0", nil},
+		{"port", 70000, ErrInvalidPort},
+		{"port", -1, ErrInvalidPort},
+		{"port", "http", ErrInvalidPort},
+		{"port", []int{80, 443}, nil},
+
+		{"minlen=2", "ab", nil},
+		{"minlen=2", "ä", ErrTooShort},
+		{"minlen=2", []int{1}, ErrTooShort},
+		{"maxlen=2", "äö", nil},
+		{"maxlen=2", "abc", ErrTooLong},
+		{"maxlen=2", []int{1, 2, 3}, ErrTooLong},
+		{"maxlen=1", map[string]int{"a": 1, "b": 2}, ErrTooLong},
+
+		{"unique", []string{"a", "b"}, nil},
+		{"unique", []string{"a", "b", "a"}, ErrNotUnique},
+		{"unique", []int{1, 1}, ErrNotUnique},
+
+		{"file_exists", file, nil},
+		{"file_exists", dir, ErrFileNotFound},
+		{"file_exists", filepath.Join(dir, "missing"), ErrFileNotFound},
+		{"dir_exists", dir, nil},
+		{"dir_exists", file, ErrDirNotFound},
+	}
+
+	for _, test := range tests {
+		name := fmt.Sprintf("%v %v", test.tag, test.value)
+		t.Run(name, func(t *testing.T) {
+			tags, err := parseValidatorTags(test.tag, nil)
+			require.NoError(t, err)
+
+			err = runValidators(test.value, tags)
+			if test.reason == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, test.reason), "unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestBuiltinValidatorsUnpack(t *testing.T) {
+	type settings struct {
+		Hosts    []string `config:"hosts" validate:"required,hostport,unique"`
+		Protocol string   `config:"protocol" validate:"oneof=http https"`
+		Name     string   `config:"name" validate:"maxlen=4"`
+	}
+
+	cfg := MustNewFrom(map[string]interface{}{
+		"hosts":    []string{"localhost:9200"},
+		"protocol": "http",
+	})
+	var s settings
+	require.NoError(t, cfg.Unpack(&s))
+
+	cfg = MustNewFrom(map[string]interface{}{
+		"hosts":    []string{"localhost:9200"},
+		"protocol": "ftp",
+	})
+	err := cfg.Unpack(&s)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrNotOneOf), "unexpected error: %v", err)
+	assert.Equal(t, "value not allowed: 'ftp' is not one of 'http', 'https' accessing 'protocol'", err.Error())
+}
+
+func TestValidatorTagEscapedComma(t *testing.T) {
+	type settings struct {
+		Name string `config:"name" validate:"required,pattern=^a{1\\,3}$"`
+	}
+
+	var s settings
+	require.NoError(t, MustNewFrom(map[string]interface{}{"name": "aa"}).Unpack(&s))
+
+	err := MustNewFrom(map[string]interface{}{"name": "aaaa"}).Unpack(&s)
+	assert.True(t, errors.Is(err, ErrPatternMismatch), "unexpected error: %v", err)
+}
+
+func TestValidatorRegistry(t *testing.T) {
+	errOdd := errors.New("odd value")
+	validateEven := func(v interface{}, _ string) error {
+		if i, ok := v.(int); ok && i%2 != 0 {
+			return errOdd
+		}
+		return nil
+	}
+
+	registry := NewValidatorRegistry()
+	require.NoError(t, registry.Register("even", validateEven))
+	assert.Equal(t, ErrDuplicateValidator, registry.Register("even", validateEven))
+	assert.Equal(t, ErrDuplicateValidator, registry.Register("min", validateEven))
+	assert.Equal(t, ErrDuplicateValidator, registry.Register("required_if", validateEven))
+	assert.True(t, registry.Has("even"))
+	assert.True(t, registry.Has("required"))
+	assert.False(t, defaultValidators.Has("even"))
+
+	var settings struct {
+		I int `config:"i" validate:"even,positive"`
+	}
+
+	cfg := MustNewFrom(map[string]interface{}{"i": 3})
+	err := cfg.Unpack(&settings, Validators(registry))
+	assert.True(t, errors.Is(err, errOdd), "unexpected error: %v", err)
+
+	// the validator is not available in the global registry
+	err = cfg.Unpack(&settings)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown validator 'even'")
+
+	assert.True(t, registry.Unregister("even"))
+	assert.False(t, registry.Unregister("even"))
+	assert.True(t, registry.Unregister("positive"))
+	assert.False(t, registry.Has("positive"))
+	assert.True(t, defaultValidators.Has("positive"))
+	err = cfg.Unpack(&settings, Validators(registry))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown validator 'even'")
+}
+
+func TestValidatorRegistryZeroValue(t *testing.T) {
+	var registry ValidatorRegistry
+	assert.True(t, registry.Has("required"))
+	assert.False(t, registry.Has("even"))
+
+	require.NoError(t, registry.Register("even", func(interface{}, string) error { return nil }))
+	assert.True(t, registry.Has("even"))
+	assert.True(t, registry.Has("required"))
+	assert.True(t, registry.Unregister("even"))
+}
+
+func TestValidatorRegistryClone(t *testing.T) {
+	errGlobal := errors.New("global validator")
+	require.NoError(t, RegisterValidator("test_clone_global", func(interface{}, string) error {
+		return errGlobal
+	}))
+	defer defaultValidators.Unregister("test_clone_global")
+
+	registry := DefaultValidatorRegistry().Clone()
+	require.NoError(t, registry.Register("test_clone_local", func(interface{}, string) error { return nil }))
+	assert.True(t, registry.Has("test_clone_global"))
+	assert.True(t, registry.Has("required"))
+	assert.False(t, defaultValidators.Has("test_clone_local"))
+
+	var settings struct {
+		I int `config:"i" validate:"test_clone_local,test_clone_global"`
+	}
+	err := MustNewFrom(map[string]interface{}{"i": 1}).Unpack(&settings, Validators(registry))
+	assert.True(t, errors.Is(err, errGlobal), "unexpected error: %v", err)
+
+	var zero ValidatorRegistry
+	assert.True(t, zero.Clone().Has("required"))
+}
+
+func TestValidatorRegistryConcurrent(t *testing.T) {
+	registry := NewValidatorRegistry()
+	cfg := MustNewFrom(map[string]interface{}{"i": 1})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("concurrent%v", i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var settings struct {
+				I int `config:"i" validate:"nonzero,min=1"`
+			}
+			assert.NoError(t, registry.Register(name, validateNonZero))
+			assert.NoError(t, cfg.Unpack(&settings, Validators(registry)))
+			assert.True(t, registry.Unregister(name))
+		}()
+	}
+	wg.Wait()
+}
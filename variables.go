@@ -218,6 +218,51 @@ func (s *splice) eval(cfg *Config, opts *options) (string, error) {
 	return buf.String(), nil
 }
 
+// formatVarExp formats e using the variable expansion syntax, such that the
+// expression can be parsed again.
+func formatVarExp(e varEvaler) string {
+	var b strings.Builder
+	writeVarExp(&b, e, false)
+	return b.String()
+}
+
+func writeVarExp(b *strings.Builder, e varEvaler, inVar bool) {
+	switch e := e.(type) {
+	case constExp:
+		s := strings.ReplaceAll(string(e), "$", "$$")
+		if inVar {
+			s = strings.ReplaceAll(s, "}", "$}")
+		}
+		b.WriteString(s)
+	case *reference:
+		b.WriteString("${" + e.Path.String() + "}")
+	case *expansionSingle:
+		b.WriteString("${")
+		writeVarExp(b, e.evaler, true)
+		b.WriteString("}")
+	case *expansionDefault:
+		writeOpExpansion(b, e.expansion, opDefault)
+	case *expansionAlt:
+		writeOpExpansion(b, e.expansion, opAlternative)
+	case *expansionErr:
+		writeOpExpansion(b, e.expansion, opError)
+	case *splice:
+		for _, p := range e.pieces {
+			writeVarExp(b, p, inVar)
+		}
+	default:
+		fmt.Fprint(b, e)
+	}
+}
+
+func writeOpExpansion(b *strings.Builder, e expansion, op string) {
+	b.WriteString("${")
+	writeVarExp(b, e.left, true)
+	b.WriteString(op)
+	writeVarExp(b, e.right, true)
+	b.WriteString("}")
+}
+
 func (e *expansion) String() string {
 	return fmt.Sprintf("${%v:%v}", e.left, e.right)
 }
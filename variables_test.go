@@ -69,6 +69,8 @@ func TestVarExpParserSuccess(t *testing.T) {
 			exp(opDefault, str("test"), str("abc}def"))},
 		{"exp with default containing :", "${test:http://default:1234}",
 			exp(opDefault, str("test"), str("http://default:1234"))},
+		{"reference with quoted name", `${labels["app.kubernetes.io/name"]}`,
+			ref(`labels["app.kubernetes.io/name"]`)},
 	}
 
 	for _, test := range tests {
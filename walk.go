@@ -0,0 +1,238 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ucfg
+
+import (
+	"errors"
+	"strconv"
+)
+
+// Kind describes the type of a setting visited by Walk.
+type Kind uint8
+
+// WalkFunc is called by Walk for every setting. The path is relative to the
+// configuration Walk has been called on, using the configured PathSep or "."
+// by default. Names containing the separator or brackets are quoted (e.g.
+// 'labels["app.kubernetes.io/name"]'), such that the path can be passed to
+// Get. For KindDict and KindArray, v is the *Config holding the settings.
+// For KindDynamic, v is the unresolved variable expression as string. For all
+// other kinds, v is the primitive value.
+//
+// If WalkFunc returns SkipChildren while visiting a dictionary or array in pre
+// order, the children of the setting are not visited. If WalkFunc returns
+// SkipAll, the walk is stopped. Any other error stops the walk and is returned
+// by Walk.
+type WalkFunc func(path string, kind Kind, v interface{}, meta *Meta) error
+
+const (
+	// KindNil marks a setting explicitly set to null.
+	KindNil Kind = iota
+
+	// KindBool marks a boolean setting.
+	KindBool
+
+	// KindInt marks a signed integer setting.
+	KindInt
+
+	// KindUint marks an unsigned integer setting.
+	KindUint
+
+	// KindFloat marks a floating point setting.
+	KindFloat
+
+	// KindString marks a string setting.
+	KindString
+
+	// KindDict marks a dictionary of named settings.
+	KindDict
+
+	// KindArray marks an array of settings.
+	KindArray
+
+	// KindDynamic marks a setting holding an unresolved variable expression.
+	KindDynamic
+)
+
+var (
+	// SkipChildren can be returned by a WalkFunc to not visit the children of
+	// the current dictionary or array.
+	SkipChildren = errors.New("skip children")
+
+	// SkipAll can be returned by a WalkFunc to stop the walk without an error.
+	SkipAll = errors.New("skip all")
+)
+
+var kindNames = [...]string{
+	KindNil:     "nil",
+	KindBool:    "bool",
+	KindInt:     "int",
+	KindUint:    "uint",
+	KindFloat:   "float",
+	KindString:  "string",
+	KindDict:    "dict",
+	KindArray:   "array",
+	KindDynamic: "dynamic",
+}
+
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "Kind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Walk visits all settings in c, calling fn for every setting. Dictionary keys
+// are visited in the order they have been added to c, array elements by index.
+// c itself is not passed to fn.
+//
+// Settings are visited in pre order, with a dictionary or array being
+// visited before its children. If the PostOrder option is set, the children
+// are visited first.
+//
+// Variable expansions are reported as KindDynamic with the unresolved
+// expression. If the ResolveDynamic option is set, variables are resolved and
+// the resolved value is visited instead. Walk returns an error if a variable
+// can not be resolved.
+//
+// Walk supports the options: PathSep, Env, Resolve, ResolveEnv,
+// ResolveDynamic, PostOrder
+func (c *Config) Walk(fn WalkFunc, opts ...Option) error {
+	o := makeOptions(opts)
+	sep := o.pathSep
+	if sep == "" {
+		sep = "."
+	}
+
+	w := walker{fn: fn, opts: o, sep: sep}
+	err := w.walkConfig("", c)
+	if err == SkipAll {
+		return nil
+	}
+	return err
+}
+
+type walker struct {
+	fn   WalkFunc
+	opts *options
+	sep  string
+}
+
+func (w *walker) walkConfig(path string, c *Config) error {
+	for _, name := range c.fields.dictKeys() {
+		v, _ := c.fields.get(name)
+		if err := w.walkValue(w.join(path, name), v); err != nil {
+			return err
+		}
+	}
+	for i, v := range c.fields.array() {
+		if err := w.walkValue(w.joinIdx(path, i), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *walker) walkValue(path string, v value) error {
+	if d, ok := v.(*cfgDynamic); ok {
+		if !w.opts.resolveDynamic {
+			return w.visitLeaf(path, KindDynamic, string(canonicalDynamicExpr(d)), d.meta())
+		}
+
+		resolved, err := d.getValue(w.opts)
+		if err != nil {
+			return err
+		}
+		v = resolved
+	}
+
+	sub, ok := v.(cfgSub)
+	if !ok {
+		kind, x, err := walkPrimitive(v, w.opts)
+		if err != nil {
+			return err
+		}
+		return w.visitLeaf(path, kind, x, v.meta())
+	}
+
+	kind := configKind(sub.c)
+
+	if w.opts.postOrder {
+		if err := w.walkConfig(path, sub.c); err != nil {
+			return err
+		}
+		return w.visitLeaf(path, kind, sub.c, v.meta())
+	}
+
+	err := w.fn(path, kind, sub.c, v.meta())
+	if err == SkipChildren {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return w.walkConfig(path, sub.c)
+}
+
+// visitLeaf calls fn for a setting whose children are not visited afterwards,
+// ignoring SkipChildren.
+func (w *walker) visitLeaf(path string, kind Kind, v interface{}, meta *Meta) error {
+	if err := w.fn(path, kind, v, meta); err != SkipChildren {
+		return err
+	}
+	return nil
+}
+
+// join appends the field name to path, quoting names that can not be parsed
+// back by Get.
+func (w *walker) join(path, name string) string {
+	return joinPath(path, name, w.sep)
+}
+
+func (w *walker) joinIdx(path string, i int) string {
+	return joinElem(path, strconv.Itoa(i), w.sep)
+}
+
+// configKind reports c as KindArray if c only holds array elements, and as
+// KindDict otherwise.
+func configKind(c *Config) Kind {
+	if c.fields.array() != nil && len(c.fields.dict()) == 0 {
+		return KindArray
+	}
+	return KindDict
+}
+
+func walkPrimitive(v value, opts *options) (Kind, interface{}, error) {
+	var kind Kind
+	switch v.(type) {
+	case *cfgNil:
+		return KindNil, nil, nil
+	case *cfgBool:
+		kind = KindBool
+	case *cfgInt:
+		kind = KindInt
+	case *cfgUint:
+		kind = KindUint
+	case *cfgFloat:
+		kind = KindFloat
+	default:
+		kind = KindString
+	}
+
+	x, err := v.reify(opts)
+	return kind, x, err
+}
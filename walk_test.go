@@ -0,0 +1,203 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ucfg
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalk(t *testing.T) {
+	c := MustNewFrom(MapSlice{
+		{"name", "beat"},
+		{"count", -3},
+		{"ratio", 0.5},
+		{"nil", nil},
+		{"sub", MapSlice{
+			{"enabled", true},
+			{"ref", "${name}"},
+		}},
+		{"list", []interface{}{"a", map[string]interface{}{"x": uint64(1)}}},
+	}, VarExp, MetaData(Meta{Source: "test.yml"}))
+
+	type visit struct {
+		path string
+		kind Kind
+		v    interface{}
+	}
+
+	collect := func(opts ...Option) []visit {
+		var visits []visit
+		err := c.Walk(func(path string, kind Kind, v interface{}, meta *Meta) error {
+			require.NotNil(t, meta)
+			assert.Equal(t, "test.yml", meta.Source)
+			if kind == KindDict || kind == KindArray {
+				v = v.(*Config).Path(".")
+			}
+			visits = append(visits, visit{path, kind, v})
+			return nil
+		}, opts...)
+		require.NoError(t, err)
+		return visits
+	}
+
+	t.Run("pre order", func(t *testing.T) {
+		assert.Equal(t, []visit{
+			{"name", KindString, "beat"},
+			{"count", KindInt, int64(-3)},
+			{"ratio", KindFloat, 0.5},
+			{"nil", KindNil, nil},
+			{"sub", KindDict, "sub"},
+			{"sub.enabled", KindBool, true},
+			{"sub.ref", KindDynamic, "${name}"},
+			{"list", KindArray, "list"},
+			{"list.0", KindString, "a"},
+			{"list.1", KindDict, "list.1"},
+			{"list.1.x", KindUint, uint64(1)},
+		}, collect())
+	})
+
+	t.Run("post order", func(t *testing.T) {
+		assert.Equal(t, []visit{
+			{"name", KindString, "beat"},
+			{"count", KindInt, int64(-3)},
+			{"ratio", KindFloat, 0.5},
+			{"nil", KindNil, nil},
+			{"sub.enabled", KindBool, true},
+			{"sub.ref", KindDynamic, "${name}"},
+			{"sub", KindDict, "sub"},
+			{"list.0", KindString, "a"},
+			{"list.1.x", KindUint, uint64(1)},
+			{"list.1", KindDict, "list.1"},
+			{"list", KindArray, "list"},
+		}, collect(PostOrder))
+	})
+
+	t.Run("resolve dynamic", func(t *testing.T) {
+		visits := collect(ResolveDynamic, PathSep("/"))
+		assert.Contains(t, visits, visit{"sub/ref", KindString, "beat"})
+	})
+}
+
+func TestWalkSkip(t *testing.T) {
+	c := MustNewFrom(MapSlice{
+		{"a", MapSlice{{"b", 1}, {"c", 2}}},
+		{"d", []interface{}{1, 2}},
+		{"e", 3},
+	})
+
+	walk := func(fn func(path string) error, opts ...Option) ([]string, error) {
+		var paths []string
+		err := c.Walk(func(path string, _ Kind, _ interface{}, _ *Meta) error {
+			paths = append(paths, path)
+			return fn(path)
+		}, opts...)
+		return paths, err
+	}
+
+	t.Run("skip children", func(t *testing.T) {
+		paths, err := walk(func(path string) error {
+			if path == "a" || path == "e" {
+				return SkipChildren
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "d", "d.0", "d.1", "e"}, paths)
+	})
+
+	t.Run("skip children in post order", func(t *testing.T) {
+		paths, err := walk(func(path string) error {
+			if path == "a" {
+				return SkipChildren
+			}
+			return nil
+		}, PostOrder)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a.b", "a.c", "a", "d.0", "d.1", "d", "e"}, paths)
+	})
+
+	t.Run("skip all", func(t *testing.T) {
+		paths, err := walk(func(path string) error {
+			if path == "d.0" {
+				return SkipAll
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "a.b", "a.c", "d", "d.0"}, paths)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		fail := errors.New("oops")
+		paths, err := walk(func(path string) error {
+			if path == "a.b" {
+				return fail
+			}
+			return nil
+		})
+		assert.Equal(t, fail, err)
+		assert.Equal(t, []string{"a", "a.b"}, paths)
+	})
+}
+
+func TestWalkUnresolvedDynamic(t *testing.T) {
+	c := MustNewFrom(map[string]interface{}{
+		"a": "${missing:?not set}",
+	}, VarExp)
+
+	err := c.Walk(func(string, Kind, interface{}, *Meta) error { return nil }, ResolveDynamic)
+	assert.Error(t, err)
+}
+
+func TestWalkDynamicExpressions(t *testing.T) {
+	exprs := []string{
+		"${name}",
+		"http://${host}:${port}",
+		"${port:9200}",
+		"${host:+set}",
+		"${host:?host is required}",
+		"${prefix.${name}}",
+		"${name:${fallback:x}}",
+		"$${escaped} ${name}",
+		"${name:a$}b}",
+	}
+
+	for _, expr := range exprs {
+		c := MustNewFrom(map[string]interface{}{"v": expr}, VarExp)
+
+		var reported interface{}
+		err := c.Walk(func(_ string, kind Kind, v interface{}, _ *Meta) error {
+			assert.Equal(t, KindDynamic, kind, expr)
+			reported = v
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, expr, reported)
+	}
+}
+
+func TestKindString(t *testing.T) {
+	assert.Equal(t, "dict", KindDict.String())
+	assert.Equal(t, "dynamic", KindDynamic.String())
+	assert.Equal(t, "Kind(42)", fmt.Sprint(Kind(42)))
+}
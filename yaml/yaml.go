@@ -26,13 +26,14 @@ import (
 )
 
 // NewConfig creates a new configuration object from the YAML string passed via in.
+// The order of keys in the YAML document is preserved.
 func NewConfig(in []byte, opts ...ucfg.Option) (*ucfg.Config, error) {
-	var m interface{}
-	if err := yaml.Unmarshal(in, &m); err != nil {
+	var doc orderedValue
+	if err := yaml.Unmarshal(in, &doc); err != nil {
 		return nil, err
 	}
 
-	return ucfg.NewFrom(m, opts...)
+	return ucfg.NewFrom(doc.value, opts...)
 }
 
 // NewConfigWithFile loads a new configuration object from an external YAML file.
@@ -47,3 +48,77 @@ func NewConfigWithFile(name string, opts ...ucfg.Option) (*ucfg.Config, error) {
 	}, opts...)
 	return NewConfig(input, opts...)
 }
+
+// orderedValue decodes YAML mappings into ucfg.MapSlice values, keeping the
+// order of keys.
+type orderedValue struct {
+	value interface{}
+}
+
+func (o *orderedValue) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// Sequences must be checked first, as a sequence can be decoded into a
+	// yaml.MapSlice as well. Null values decode into nil slices.
+	var arr []orderedValue
+	if err := unmarshal(&arr); err == nil && arr != nil {
+		values := make([]interface{}, len(arr))
+		for i, v := range arr {
+			values[i] = v.value
+		}
+		o.value = values
+		return nil
+	}
+
+	// Nested mappings are decoded into yaml.MapSlice as well if the outer
+	// mapping is decoded into a yaml.MapSlice.
+	var m yaml.MapSlice
+	if err := unmarshal(&m); err == nil && m != nil {
+		o.value = convertValue(m)
+		return nil
+	}
+
+	return unmarshal(&o.value)
+}
+
+func convertValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		return convertMapSlice(v)
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, elem := range v {
+			values[i] = convertValue(elem)
+		}
+		return values
+	default:
+		return v
+	}
+}
+
+func convertMapSlice(m yaml.MapSlice) interface{} {
+	out := make(ucfg.MapSlice, 0, len(m))
+	index := make(map[string]int, len(m))
+	for _, item := range m {
+		key, ok := item.Key.(string)
+		if !ok {
+			// Return a map for ucfg to report the invalid key type.
+			return convertMap(m)
+		}
+
+		// like decoding into a map, the last value of duplicate keys wins
+		if i, exists := index[key]; exists {
+			out[i].Value = convertValue(item.Value)
+			continue
+		}
+		index[key] = len(out)
+		out = append(out, ucfg.MapItem{Key: key, Value: convertValue(item.Value)})
+	}
+	return out
+}
+
+func convertMap(m yaml.MapSlice) map[interface{}]interface{} {
+	out := make(map[interface{}]interface{}, len(m))
+	for _, item := range m {
+		out[item.Key] = convertValue(item.Value)
+	}
+	return out
+}
@@ -225,3 +225,80 @@ func mustUnpack(t *testing.T, c *ucfg.Config, v interface{}) {
 	err := c.Unpack(v)
 	require.NoError(t, err, "failed to unpack config")
 }
+
+func TestKeyOrder(t *testing.T) {
+	input := []byte(`
+processors:
+  - drop_fields: {fields: [a]}
+  - rename: {fields: [b]}
+zeta:
+  z: 1
+  y: 2
+  x:
+    c: 1
+    b: 2
+alpha: 1
+zeta:
+  w: 3
+`)
+
+	c, err := NewConfig(input)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"processors", "zeta", "alpha"}, c.GetFields())
+
+	zeta, err := c.Child("zeta", -1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"w"}, zeta.GetFields())
+
+	input = []byte(`
+- b: 1
+  a: 2
+- [{d: 1, c: 2}]
+`)
+	c, err = NewConfig(input)
+	require.NoError(t, err)
+
+	elem, err := c.Child("", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "a"}, elem.GetFields())
+
+	nested, err := c.Child("", 1)
+	require.NoError(t, err)
+	elem, err = nested.Child("", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"d", "c"}, elem.GetFields())
+}
+
+func TestKeysWithBrackets(t *testing.T) {
+	input := `
+    a[0]: zero
+    a["b"]: quoted
+    labels:
+      x[]: append
+      10:30: time
+  `
+	c := mustNewConfig(t, input, ucfg.PathSep("."))
+
+	var out map[string]interface{}
+	mustUnpack(t, c, &out)
+	assert.Equal(t, map[string]interface{}{
+		"a[0]":   "zero",
+		`a["b"]`: "quoted",
+		"labels": map[string]interface{}{
+			"x[]":   "append",
+			"10:30": "time",
+		},
+	}, out)
+
+	s, err := c.String(`["a[0]"]`, -1, ucfg.PathSep("."))
+	require.NoError(t, err)
+	assert.Equal(t, "zero", s)
+
+	s, err = c.String(`['a["b"]']`, -1, ucfg.PathSep("."))
+	require.NoError(t, err)
+	assert.Equal(t, "quoted", s)
+
+	s, err = c.String(`labels["10:30"]`, -1, ucfg.PathSep("."))
+	require.NoError(t, err)
+	assert.Equal(t, "time", s)
+}